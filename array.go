@@ -2,64 +2,346 @@ package jsonlight
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"strings"
+	"sync"
+	"time"
 )
 
-// re: arrays, that's not simple. because:
-// 1) slices change after being appended
+// array is either detached and owns its slice, or it is a live view
+// of a slot in parent object or array.
+// views re-read the slot on every access and write changed slice back,
+// so appends which reallocate the slice are visible to the parent.
+// if the slot is removed or stops holding an array, the view detaches
+// and keeps its last known contents, so arrays never become unusable.
+// nested view also detaches when its element is moved by another view of the same parent.
+// reads change nothing but the registry of views, so they are safe to do concurrently
 type JSONArray struct {
-	// alternative 1
-	data *[]interface{}
-	// alternative 2
-	m    JSONObject
+	// contents of detached array, views keep here what they had when created or last changed
+	data []interface{}
+	// set by NewArray(&slice): changes are written back to that slice
+	ptr *[]interface{}
+
+	// parent slot, both are nil for detached arrays
+	m    map[string]interface{}
 	mkey string
-	// alternative 3
 	a    *JSONArray
 	akey int
+	// pointer of the object which has m, used in errors
+	base string
 
-	// methods allow passing array as value
-	// however that means i cannot reattach it to new parent
-	// in this way the only hacky solution is to keep pointer to original struct
-	originalptr *JSONArray
+	// views handed out by GetArray, kept in sync when elements shift
+	children map[int]*JSONArray
+	// guards children, GetArray fills it on reads
+	mu sync.Mutex
 }
 
 func NewArray(slicee ...*[]interface{}) *JSONArray {
 	if len(slicee) > 1 {
 		panic("brrr")
 	}
-	var a *JSONArray
-	if len(slicee) == 0 {
-		x := make([]interface{}, 0)
-		a = &JSONArray{data: &x}
-	} else {
-		a = &JSONArray{data: slicee[0]}
+	if len(slicee) == 0 || slicee[0] == nil {
+		return &JSONArray{data: make([]interface{}, 0)}
+	}
+	if *slicee[0] == nil {
+		*slicee[0] = make([]interface{}, 0)
 	}
-	a.originalptr = a
+	return &JSONArray{data: *slicee[0], ptr: slicee[0]}
+}
+
+func NewEmptyArray() IArray {
+	return NewArray()
+}
+
+func NewArrayOrNil(string_or_bytes_or_reader_or_slice ...interface{}) IArray {
+	a, _ := NewArrayFrom(string_or_bytes_or_reader_or_slice...)
 	return a
 }
 
-//------------------
+func NewArrayOrDie(string_or_bytes_or_reader_or_slice ...interface{}) IArray {
+	a, e := NewArrayFrom(string_or_bytes_or_reader_or_slice...)
+	if e != nil {
+		panic(e)
+	}
+	if a == nil {
+		panic("nil array created")
+	}
+	return a
+}
 
-func (this *JSONArray) Length() int {
-	slice, ok := this.ToSlice()
+// same as NewObject, but for arrays.
+// NewArray itself is left as is because it only wraps an existing slice pointer
+func NewArrayFrom(string_or_bytes_or_reader_or_slice ...interface{}) (IArray, error) {
+	if len(string_or_bytes_or_reader_or_slice) == 0 {
+		return NewEmptyArray(), nil
+	}
+	switch p := string_or_bytes_or_reader_or_slice[0].(type) {
+	case nil:
+		return NewEmptyArray(), nil
+	case string:
+		return NewArrayFromString(p)
+	case *string:
+		if p == nil {
+			return NewEmptyArray(), nil
+		}
+		return NewArrayFromString(*p)
+	case []byte:
+		return NewArrayFromBytes(p)
+	case []interface{}:
+		return NewArrayFromSlice(p), nil
+	case *[]interface{}:
+		if p == nil {
+			return NewEmptyArray(), nil
+		}
+		return NewArray(p), nil
+	case io.Reader:
+		return NewArrayFromReader(p)
+	}
+	return nil, errors.New("unsupported input type")
+}
+
+func NewArrayFromSlice(slice []interface{}) IArray {
+	if slice == nil {
+		slice = make([]interface{}, 0)
+	}
+	return NewArray(&slice)
+}
+
+func NewArrayFromFile(url string, timeout time.Duration) (IArray, error, int) {
+	r, err, code := OpenContents(url, timeout)
+	if err != nil {
+		return nil, err, code
+	}
+	defer r.Close()
+
+	a, err := NewArrayFromReader(r)
+	return a, err, code
+}
+
+func NewArrayFromString(str string) (IArray, error) {
+	return NewArrayFromBytes([]byte(str))
+}
+
+// decodes array directly from reader.
+// optional maxSize limits amount of bytes read, TooLargeError is returned when exceeded
+func NewArrayFromReader(r io.Reader, maxSize ...int64) (IArray, error) {
+	return NewArrayFromReaderWithOptions(r, optionsWithMaxSize(maxSize...))
+}
+
+func NewArrayFromBytesWithOptions(data []byte, opts ParseOptions) (IArray, error) {
+	return NewArrayFromReaderWithOptions(bytes.NewReader(data), opts)
+}
+
+func NewArrayFromReaderWithOptions(r io.Reader, opts ParseOptions) (IArray, error) {
+	res, err := decodeReader(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	res2, ok := res.([]interface{})
 	if !ok {
-		return -1
+		return nil, typeMismatch(nil, "array", res)
+	}
+
+	return NewArrayFromSlice(res2), nil
+}
+
+func NewArrayFromBytes(bytes []byte) (IArray, error) {
+	var res interface{}
+	if err := json.Unmarshal(bytes, &res); err != nil {
+		return nil, err
+	}
+	res2, ok := res.([]interface{})
+	if !ok {
+		return nil, typeMismatch(nil, "array", res)
+	}
+
+	return NewArrayFromSlice(res2), nil
+}
+
+//------------------
+
+// true if array is a live view of parent slot
+func (this *JSONArray) attached() bool {
+	_, ok := this.slot()
+	return ok && (this.m != nil || this.a != nil)
+}
+
+// current slice, re-read from parent slot
+func (this *JSONArray) resolve() []interface{} {
+	if s, ok := this.slot(); ok {
+		return s
+	}
+	return this.data
+}
+
+// slice in the slot, false if array is detached or slot does not hold array anymore
+func (this *JSONArray) slot() ([]interface{}, bool) {
+	switch {
+	case this.ptr != nil:
+		return *this.ptr, true
+	case this.m != nil:
+		s, ok := this.m[this.mkey].([]interface{})
+		return s, ok
+	case this.a != nil:
+		ps := this.a.resolve()
+		if this.akey < len(ps) && this.a.child(this.akey) == this {
+			// another view of the parent may have moved elements without telling this one,
+			// then the slot holds some other array
+			s, ok := ps[this.akey].([]interface{})
+			return s, ok && sameStorage(s, this.data)
+		}
+	}
+	return nil, false
+}
+
+// true if slices share backing array, so they are the same array, maybe grown in place
+func sameStorage(a, b []interface{}) bool {
+	if cap(a) == 0 || cap(b) == 0 {
+		return cap(a) == cap(b)
+	}
+	return &a[:1][0] == &b[:1][0]
+}
+
+// view registered for element, nil if there is none
+func (this *JSONArray) child(index int) *JSONArray {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.children[index]
+}
+
+// detaches view of element which is going to be overwritten, view keeps its current contents
+func (this *JSONArray) release(index int) {
+	if c := this.child(index); c != nil {
+		c.data = c.resolve()
+		c.detach()
+	}
+}
+
+// saves new slice, writing it to parent slot.
+// view of a slot which does not hold array anymore detaches instead
+func (this *JSONArray) store(s []interface{}) {
+	if _, ok := this.slot(); !ok {
+		this.detach()
+	}
+	this.data = s
+	switch {
+	case this.ptr != nil:
+		*this.ptr = s
+	case this.m != nil:
+		this.m[this.mkey] = s
+	case this.a != nil:
+		this.a.resolve()[this.akey] = s
+	}
+}
+
+// location from the object the array was taken from, "" for detached arrays
+func (this *JSONArray) pointer() string {
+	switch {
+	case this.m != nil:
+		return this.base + keyPath(this.mkey)
+	case this.a != nil:
+		return this.a.pointer() + keyPath(this.akey)
+	}
+	return ""
+}
+
+func (this *JSONArray) located(err error) error {
+	return locateError(err, this.pointer())
+}
+
+// forgets parent, contents stay as they were
+func (this *JSONArray) detach() {
+	if p := this.a; p != nil {
+		p.mu.Lock()
+		if p.children[this.akey] == this {
+			delete(p.children, this.akey)
+		}
+		p.mu.Unlock()
 	}
-	return len(slice)
+	this.m, this.mkey, this.a, this.akey, this.base = nil, "", nil, 0, ""
+	if this.data == nil {
+		this.data = make([]interface{}, 0)
+	}
+}
+
+// views of removed elements are detached, views of elements after them are moved
+func (this *JSONArray) spliceChildren(index, removed, inserted int) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if len(this.children) == 0 {
+		return
+	}
+	moved := make(map[int]*JSONArray, len(this.children))
+	for i, c := range this.children {
+		switch {
+		case i < index:
+			moved[i] = c
+		case i < index+removed:
+			c.a = nil
+			c.detach()
+		default:
+			c.akey = i + inserted - removed
+			moved[c.akey] = c
+		}
+	}
+	this.children = moved
+}
+
+// replaces del elements at index with add nil slots, returns removed elements.
+// index and counts must be checked by caller
+func (this *JSONArray) splice(index, del, add int) []interface{} {
+	s := this.resolve()
+	for i := index; i < index+del; i++ {
+		if c := this.child(i); c != nil {
+			// detached view keeps latest contents
+			c.data = c.resolve()
+		}
+	}
+	removed := append([]interface{}(nil), s[index:index+del]...)
+	n := len(s)
+	if add > del {
+		s = append(s, make([]interface{}, add-del)...)
+	}
+	copy(s[index+add:], s[index+del:n])
+	for i := n + add - del; i < n; i++ {
+		s[i] = nil
+	}
+	s = s[:n+add-del]
+	for i := index; i < index+add; i++ {
+		s[i] = nil
+	}
+	this.store(s)
+	this.spliceChildren(index, del, add)
+	return removed
+}
+
+// true if this is a or is nested into a
+func (this *JSONArray) insideOf(a *JSONArray) bool {
+	for x := this; x != nil; x = x.a {
+		if x == a {
+			return true
+		}
+		if !x.attached() {
+			break
+		}
+	}
+	return false
+}
+
+func (this *JSONArray) Length() int {
+	return len(this.resolve())
 }
 
 func (this *JSONArray) ToString(indentFactor ...int) string {
 	return string(this.ToByteArray(indentFactor...))
 }
 func (this *JSONArray) ToByteArray(indentFactor ...int) []byte {
-	a, ok := this.ToSlice()
-	if !ok {
-		return []byte("<EXPIREDARRAY>")
-	}
+	a := this.resolve()
 
 	var x []byte
 	if len(indentFactor) > 0 {
@@ -68,14 +350,11 @@ func (this *JSONArray) ToByteArray(indentFactor ...int) []byte {
 		x, _ = json.Marshal(a)
 	}
 
-	return x // return fmt.Sprintf("%+v", *(this.data))
+	return x
 }
 
 func (this *JSONArray) Join(separator string) string {
-	a, ok := this.ToSlice()
-	if !ok {
-		return "<EXPIREDARRAY>"
-	}
+	a := this.resolve()
 
 	var buffer bytes.Buffer
 
@@ -91,204 +370,301 @@ func (this *JSONArray) Join(separator string) string {
 
 func (this *JSONArray) Write(writer *io.Writer) {
 	enc := json.NewEncoder(*writer)
-	enc.Encode(this.data)
+	enc.Encode(this.resolve())
 }
 
 func (this *JSONArray) ToSliceOrDie() []interface{} {
-	x, ok := this.ToSlice()
-	if !ok {
-		panic("failed getting slice from Array")
-	}
-	return x
+	return this.resolve()
 }
 
+// second result is always true, it is left from the times when arrays could expire
 func (this *JSONArray) ToSlice() ([]interface{}, bool) {
-	if this.data != nil {
-		return *(this.data), true
-	} else if this.a != nil {
-		parentslice, ok := this.a.ToSlice()
-		if !ok {
-			return nil, false
-		}
-		elem := parentslice[this.akey] //this.a.Get(this.akey)
-		//println("xxx", Dump(&elem))
-		if !ok || isNil(&elem) {
-			return nil, false
-		}
-		res, ok2 := elem.([]interface{})
-		if !ok2 {
-			return nil, false
-		}
-		return res, true
-	} else {
-		elem, ok := this.m[this.mkey]
-		if !ok || isNil(&elem) {
-			return nil, false
-		}
-		res, ok2 := elem.([]interface{})
-		if !ok2 {
-			return nil, false
-		}
+	return this.resolve(), true
+}
 
-		return res, true
-	}
+// deep copy which is not attached anywhere
+func (this *JSONArray) Copy() IArray {
+	return NewArrayFromSlice(DeepCopy(this.resolve()).([]interface{}))
 }
 
 func (this *JSONArray) Remove(index int) interface{} {
-	var removed interface{}
-
-	if this.data != nil {
-		s := *(this.data)
-		removed = s[index]
-		s = append(s[:index], s[index+1:]...)
-		*(this.data) = s
-	} else if this.a != nil {
-		s, ok := this.ToSlice() //this.a.Get(this.akey).([]interface{})
-		if !ok {
-			return nil
-		}
-		removed = s[index]
-		s = append(s[:index], s[index+1:]...)
-		this.a.Put(this.akey, s)
-	} else {
-		mapp := this.m.ToMap()
-		s, ok := this.ToSlice() //mapp[this.mkey].([]interface{})
-		if !ok {
-			return nil
-		}
-		removed = s[index]
-		s = append(s[:index], s[index+1:]...)
-		mapp[this.mkey] = s
+	if index < 0 || index >= this.Length() {
+		return nil
 	}
-
-	return removed
+	return this.splice(index, 1, 0)[0]
 }
 
-// TODO need to return error if wasn't able to detach well
+// removes array from its parent, after that array owns its contents
 func (this *JSONArray) DetachFromParent() {
-	if this.data != nil {
-		return
-	} else if this.a != nil {
-		slice, ok := this.ToSlice()
+	s := this.resolve()
+	switch {
+	case !this.attached():
+	case this.m != nil:
+		delete(this.m, this.mkey)
+	case this.a != nil:
 		this.a.Remove(this.akey)
-		this.a = nil
-		this.akey = -1
-		if !ok {
-			return
-		}
-		this.data = &slice
-	} else {
-		slice, ok := this.ToSlice()
-		this.m.Remove(this.mkey)
-		this.m = nil
-		this.mkey = ""
-		if !ok {
-			return
-		}
-		this.data = &slice
 	}
+	this.data = s
+	this.ptr = nil
+	this.detach()
 }
 
-// can be optimized
-// TODO handle slice not ok issue
 func (this *JSONArray) Append(v ...interface{}) IArray {
-	// println("appending ", Dump(&v[0]), " to ", fmt.Sprintf("%+v", this), this.ToString())
-	a, ok := this.ToSlice()
-	if !ok {
-		return nil
+	s := this.resolve()
+	base := len(s)
+	this.store(append(s, make([]interface{}, len(v))...))
+	for i := 0; i < len(v); i++ {
+		this.Put(base+i, v[i])
 	}
+	return this
+}
 
-	baseindex := len(a)
+// makes a to be view of index, or stores its copy if it is attached elsewhere
+func (this *JSONArray) putArray(index int, a *JSONArray) (interface{}, error) {
+	s := this.resolve()
+	prev := s[index]
+	if a.attached() {
+		s[index] = DeepCopy(a.resolve())
+		return prev, nil
+	}
+
+	this.release(index)
+	contents := a.resolve()
+	s[index] = contents
+	// a may still remember slot it has lost
+	a.ptr = nil
+	a.detach()
+	a.data = contents
+	this.mu.Lock()
+	if this.children == nil {
+		this.children = map[int]*JSONArray{}
+	}
+	this.children[index] = a
+	this.mu.Unlock()
+	a.a, a.akey = this, index
+	return prev, nil
+}
 
-	newa := a
-	for i := 0; i < len(v); i++ {
-		aa, isArray := v[i].(JSONArray)
-		oldlen := this.Length()
-		if isArray {
-			(&aa).DetachFromParent()
-		} else {
-			paa, isArray := v[i].(*JSONArray)
-			if isArray {
-				(paa).DetachFromParent()
-			}
-		}
-		newlen := this.Length()
-		if oldlen != newlen {
-			baseindex--
-		} else {
-			newa = append(newa, nil)
-		}
+func (this *JSONArray) Put(index int, v interface{}) (interface{}, error) { // XJSON
+	a := this.resolve()
+	if index < 0 || index >= len(a) {
+		return nil, PutError{Key: index, Reason: "index out of range", Err: notFound(index)}
 	}
 
-	this.updateParent(newa)
+	if err := this.checkPut(index, v); err != nil {
+		return nil, err
+	}
 
-	for i := 0; i < len(v); i++ {
+	prev := a[index]
+	switch v.(type) {
+	case []interface{}, JSONArray, *JSONArray:
+		// view of the element stays with the slot
+	default:
+		this.release(index)
+	}
+	switch vv := v.(type) {
+	case *big.Int:
+		a[index] = json.Number(vv.String())
+	case *JSONArray:
+		return this.putArray(index, vv)
+	case *JSONObject:
+		a[index] = *vv
+	case JSONArray:
+		// copy of the struct cannot be a view, so only contents are stored
+		a[index] = DeepCopy(vv.resolve())
+	default:
+		a[index] = v
+	}
+	if s, ok := a[index].([]interface{}); ok {
+		if c := this.child(index); c != nil {
+			c.data = s
+		}
+	}
 
-		this.Put(baseindex+i, v[i])
+	return prev, nil
+}
+
+// error which Put would return for v, so bulk operations can check values before changing anything
+func (this *JSONArray) checkPut(index int, v interface{}) error {
+	switch vv := v.(type) {
+	default:
+		return PutError{Key: index, ValueType: fmt.Sprintf("%T", vv), Reason: "unsupported value type"}
+	case nil, JSONObject, JSONArray, []interface{}, bool, float32, float64, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, string, map[string]interface{}, json.Number:
+	case *big.Int:
+		if vv == nil {
+			return PutError{Key: index, ValueType: "*big.Int", Reason: "nil pointer"}
+		}
+	case *JSONObject:
+		if vv == nil {
+			return PutError{Key: index, ValueType: "*jsonlight.JSONObject", Reason: "nil pointer"}
+		}
+	case *OrderedObject:
+		if vv == nil {
+			return PutError{Key: index, ValueType: "*jsonlight.OrderedObject", Reason: "nil pointer"}
+		}
+	case *JSONArray:
+		if vv == nil {
+			return PutError{Key: index, ValueType: "*jsonlight.JSONArray", Reason: "nil pointer"}
+		}
+		if this.insideOf(vv) {
+			return PutError{Key: index, ValueType: "*jsonlight.JSONArray", Reason: "array cannot contain itself"}
+		}
 	}
+	return nil
+}
 
-	return this
+// inserts values before index, index == Length() appends them.
+// nothing is changed if any of values cannot be stored
+func (this *JSONArray) Insert(index int, v ...interface{}) error {
+	if index < 0 || index > this.Length() {
+		return PutError{Key: index, Reason: "index out of range", Err: notFound(index)}
+	}
+	for i := range v {
+		if err := this.checkPut(index+i, v[i]); err != nil {
+			return err
+		}
+	}
+	this.splice(index, 0, len(v))
+	for i := range v {
+		this.Put(index+i, v[i])
+	}
+	return nil
 }
 
-// TODO handle expired slice
-func (this *JSONArray) updateParent(v []interface{}) {
-	slice := v
-	if this.data != nil {
-		this.data = &slice
-	} else if this.a != nil {
-		parentslice, ok := this.a.ToSlice()
-		if !ok {
-			return
+// removes up to deleteCount elements at index and inserts values there, like in javascript.
+// returns removed elements
+func (this *JSONArray) Splice(index int, deleteCount int, v ...interface{}) ([]interface{}, error) {
+	n := this.Length()
+	if index < 0 || index > n {
+		return nil, PutError{Key: index, Reason: "index out of range", Err: notFound(index)}
+	}
+	if deleteCount < 0 {
+		deleteCount = 0
+	}
+	if deleteCount > n-index {
+		deleteCount = n - index
+	}
+	for i := range v {
+		if err := this.checkPut(index+i, v[i]); err != nil {
+			return nil, err
 		}
-		parentslice[this.akey] = slice
-	} else {
-		this.m.Put(this.mkey, slice)
 	}
+	removed := this.splice(index, deleteCount, len(v))
+	for i := range v {
+		this.Put(index+i, v[i])
+	}
+	return removed, nil
 }
 
-func (this *JSONArray) Put(index int, v interface{}) (interface{}, error) { // XJSON
-	a, ok := this.ToSlice()
-	if !ok {
-		return nil, errors.New("Expired array")
+// detached copy of elements [from, to).
+// negative indexes count from the end, out of range ones are clamped
+func (this *JSONArray) Slice(from, to int) IArray {
+	s := this.resolve()
+	from, to = clampIndex(from, len(s)), clampIndex(to, len(s))
+	if from >= to {
+		return NewEmptyArray()
 	}
-	if index < 0 || index >= len(a) {
-		return nil, errors.New("Array.Put: index out of range")
+	return NewArrayFromSlice(DeepCopy(s[from:to]).([]interface{}))
+}
+
+func clampIndex(i, n int) int {
+	if i < 0 {
+		i += n
+	}
+	if i < 0 {
+		return 0
 	}
+	if i > n {
+		return n
+	}
+	return i
+}
 
-	var prev interface{}
+// index of first element equal to v (see ValuesEqual) or -1
+func (this *JSONArray) IndexOf(v interface{}) int {
+	for i, x := range this.resolve() {
+		if ValuesEqual(x, v) {
+			return i
+		}
+	}
+	return -1
+}
 
-	switch vv := v.(type) {
-	default:
-		return nil, errors.New(fmt.Sprintf("Array.Put: unexpected type %T", vv))
-	case JSONObject, []interface{}, bool, float32, float64, int8, int16, int32, int64, uint8, uint16, uint32, uint64, string, map[string]interface{}:
-		prev = a[index]
-		a[index] = v
-	case *JSONArray:
-		p := v.(*JSONArray)
-		if p == this {
-			return nil, errors.New("Array.Put: self-referencing detected")
+func (this *JSONArray) LastIndexOf(v interface{}) int {
+	s := this.resolve()
+	for i := len(s) - 1; i >= 0; i-- {
+		if ValuesEqual(s[i], v) {
+			return i
 		}
-		return this.Put(index, *(p))
-	case *JSONObject:
-		return this.Put(index, *(v.(*JSONObject)))
-	case JSONArray:
-		y := v.(JSONArray).originalptr
-		slice, ok := y.ToSlice()
-		if !ok {
-			return nil, errors.New("Array.Put: attempt to put expired array")
+	}
+	return -1
+}
+
+func (this *JSONArray) Contains(v interface{}) bool {
+	return this.IndexOf(v) >= 0
+}
+
+func (this *JSONArray) Reverse() IArray {
+	s := this.resolve()
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if len(this.children) > 0 {
+		moved := make(map[int]*JSONArray, len(this.children))
+		for i, c := range this.children {
+			// views of elements which are gone already are dropped
+			if i < len(s) {
+				c.akey = len(s) - 1 - i
+				moved[c.akey] = c
+			}
 		}
-		y.DetachFromParent()
-		prev = a[index]
-		a[index] = slice
+		this.children = moved
+	}
+	return this
+}
 
-		y.data = nil
-		y.a = this
-		y.akey = index
-		y.m = nil
-		y.mkey = ""
+func (this *JSONArray) Swap(i, j int) error {
+	s := this.resolve()
+	for _, k := range []int{i, j} {
+		if k < 0 || k >= len(s) {
+			return PutError{Key: k, Reason: "index out of range", Err: notFound(k)}
+		}
 	}
+	s[i], s[j] = s[j], s[i]
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	ci, cj := this.children[i], this.children[j]
+	delete(this.children, i)
+	delete(this.children, j)
+	if ci != nil {
+		ci.akey = j
+		this.children[j] = ci
+	}
+	if cj != nil {
+		cj.akey = i
+		this.children[i] = cj
+	}
+	return nil
+}
 
-	return prev, nil
+// removes all elements, views of them are detached
+func (this *JSONArray) Clear() IArray {
+	this.splice(0, this.Length(), 0)
+	return this
+}
+
+// new detached array with copies of elements of this and others
+func (this *JSONArray) Concat(others ...IArray) IArray {
+	res := DeepCopy(this.resolve()).([]interface{})
+	for _, o := range others {
+		if o == nil {
+			continue
+		}
+		res = append(res, DeepCopy(o.ToSliceOrDie()).([]interface{})...)
+	}
+	return NewArrayFromSlice(res)
 }
 
 //--------------------------------------
@@ -314,64 +690,79 @@ func (this *JSONArray) Get(index int) (interface{}, bool) {
 	return slice[index], true
 }
 
+// returns live view, the same one for the same element
 func (this *JSONArray) GetArray(index int) (IArray, error) { // XJSON
-	m, ok := this.ToSlice()
-	if !ok || m == nil {
-		return nil, ArrayExpiredError{}
-	}
+	m := this.resolve()
 	if index < 0 || index >= len(m) {
-		return nil, NotFoundError{}
+		return nil, this.located(notFound(index))
 	}
 
 	v := m[index]
-	_, arrok := v.([]interface{})
+	s, arrok := v.([]interface{})
 	if !arrok {
-		return nil, TypeConvertError{}
+		return nil, this.located(typeMismatch(index, "array", v))
+	}
+	if c := this.child(index); c != nil {
+		if c.attached() {
+			return c, nil
+		}
+		// element was replaced behind the view's back, e.g. through another view of this array
+		c.detach()
+	}
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	if c := this.children[index]; c != nil {
+		return c, nil
 	}
-	return &JSONArray{a: this, akey: index}, nil
+	if this.children == nil {
+		this.children = map[int]*JSONArray{}
+	}
+	c := &JSONArray{data: s, a: this, akey: index}
+	this.children[index] = c
+	return c, nil
 }
 
 func (this *JSONArray) GetBoolean(index int) (bool, error) {
 	a, ok := this.Get(index)
 	if !ok {
-		return false, NotFoundError{}
+		return false, this.located(notFound(index))
 	}
 	if isNil(&a) {
-		return false, NilConvertError{}
+		return false, this.located(nilValue(index, "boolean"))
 	}
 	if v, ok := a.(bool); ok {
 		return v, nil
 	}
-	return false, TypeConvertError{}
+	return false, this.located(typeMismatch(index, "boolean", a))
 }
 func (this *JSONArray) GetString(index int) (string, error) {
 	a, ok := this.Get(index)
 	if !ok {
-		return "", NotFoundError{}
+		return "", this.located(notFound(index))
 	}
 	if isNil(&a) {
-		return "", NilConvertError{}
+		return "", this.located(nilValue(index, "string"))
 	}
 	if v, ok := a.(string); ok {
 		return v, nil
 	}
-	return "", TypeConvertError{}
+	return "", this.located(typeMismatch(index, "string", a))
 }
 func (this *JSONArray) GetDouble(index int) (float64, error) {
 	a, ok := this.Get(index)
 	if !ok {
-		return 0, NotFoundError{}
+		return 0, this.located(notFound(index))
 	}
 	if isNil(&a) {
-		return 0, NilConvertError{}
-	}
-	if iv, ok := IntValue(a); ok {
-		return float64(iv), nil
+		return 0, this.located(nilValue(index, "number"))
 	}
 	if v, ok := FloatValue(a); ok {
 		return v, nil
 	}
-	return 0, TypeConvertError{}
+	if iv, ok := IntValue(a); ok {
+		return float64(iv), nil
+	}
+	return 0, this.located(typeMismatch(index, "number", a))
 }
 func (this *JSONArray) GetInt(index int) (int, error) {
 	long, err := this.GetLong(index)
@@ -381,36 +772,118 @@ func (this *JSONArray) GetInt(index int) (int, error) {
 	return int(long), nil
 }
 func (this *JSONArray) GetObject(index int) (IObject, error) {
-	m, ok := this.ToSlice()
-	if !ok {
-		return nil, ArrayExpiredError{}
-	}
+	m := this.resolve()
 	if index < 0 || index >= len(m) {
-		return nil, NotFoundError{}
+		return nil, this.located(notFound(index))
 	}
 	v := m[index]
-	if !ok {
-		return nil, NotFoundError{}
-	}
-	mm, arrok := v.(map[string]interface{})
-	if !arrok {
-		return nil, TypeConvertError{}
+	res, objok := ObjectValue(v)
+	if !objok {
+		return nil, this.located(typeMismatch(index, "object", v))
 	}
-	res, eee := NewObject(mm)
-	return res, eee
+	return res, nil
 }
 func (this *JSONArray) GetLong(index int) (int64, error) {
 	a, ok := this.Get(index)
 	if !ok {
-		return 0, NotFoundError{}
+		return 0, this.located(notFound(index))
 	}
 	if isNil(&a) {
-		return 0, NilConvertError{}
+		return 0, this.located(nilValue(index, "integer"))
 	}
 	if iv, ok := IntValue(a); ok {
 		return iv, nil
 	}
-	return 0, TypeConvertError{}
+	return 0, this.located(typeMismatch(index, "integer", a))
+}
+
+// fails instead of rounding, use ParseOptions.UseNumber to keep big numbers exact
+func (this *JSONArray) GetBigInt(index int) (*big.Int, error) {
+	a, ok := this.Get(index)
+	if !ok {
+		return nil, this.located(notFound(index))
+	}
+	if isNil(&a) {
+		return nil, this.located(nilValue(index, "integer"))
+	}
+	if v, ok := BigIntValue(a); ok {
+		return v, nil
+	}
+	return nil, this.located(typeMismatch(index, "integer", a))
+}
+func (this *JSONArray) GetUint64(index int) (uint64, error) {
+	a, ok := this.Get(index)
+	if !ok {
+		return 0, this.located(notFound(index))
+	}
+	if isNil(&a) {
+		return 0, this.located(nilValue(index, "integer"))
+	}
+	if v, ok := Uint64Value(a); ok {
+		return v, nil
+	}
+	return 0, this.located(typeMismatch(index, "integer", a))
+}
+
+// RFC 3339 string, custom layouts or unix seconds/millis number, see TimeValue
+func (this *JSONArray) GetTime(index int, layouts ...string) (time.Time, error) {
+	a, ok := this.Get(index)
+	if !ok {
+		return time.Time{}, this.located(notFound(index))
+	}
+	if isNil(&a) {
+		return time.Time{}, this.located(nilValue(index, "time"))
+	}
+	if v, ok := TimeValue(a, layouts...); ok {
+		return v, nil
+	}
+	return time.Time{}, this.located(typeMismatch(index, "time", a))
+}
+
+// "1m30s" or number of seconds
+func (this *JSONArray) GetDuration(index int) (time.Duration, error) {
+	a, ok := this.Get(index)
+	if !ok {
+		return 0, this.located(notFound(index))
+	}
+	if isNil(&a) {
+		return 0, this.located(nilValue(index, "duration"))
+	}
+	if v, ok := DurationValue(a); ok {
+		return v, nil
+	}
+	return 0, this.located(typeMismatch(index, "duration", a))
+}
+
+// base64 string, standard or url encoding
+func (this *JSONArray) GetBytes(index int) ([]byte, error) {
+	a, ok := this.Get(index)
+	if !ok {
+		return nil, this.located(notFound(index))
+	}
+	if isNil(&a) {
+		return nil, this.located(nilValue(index, "base64"))
+	}
+	if v, ok := BytesValue(a); ok {
+		return v, nil
+	}
+	return nil, typeMismatch(index, "base64", a)
+}
+
+// layout is time.RFC3339Nano by default
+func (this *JSONArray) PutTime(index int, t time.Time, layout ...string) (interface{}, error) {
+	if len(layout) > 0 {
+		return this.Put(index, t.Format(layout[0]))
+	}
+	return this.Put(index, t.Format(time.RFC3339Nano))
+}
+func (this *JSONArray) PutDuration(index int, d time.Duration) (interface{}, error) {
+	return this.Put(index, d.String())
+}
+
+// standard base64
+func (this *JSONArray) PutBytes(index int, b []byte) (interface{}, error) {
+	return this.Put(index, base64.StdEncoding.EncodeToString(b))
 }
 
 //-------------------------------------------------
@@ -435,6 +908,36 @@ func (this *JSONArray) OptBoolean(index int, defaultvalue ...bool) bool {
 	}
 	return v
 }
+func (this *JSONArray) OptTime(index int, defaultvalue ...time.Time) time.Time {
+	v, err := this.GetTime(index)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return time.Time{}
+	}
+	return v
+}
+func (this *JSONArray) OptDuration(index int, defaultvalue ...time.Duration) time.Duration {
+	v, err := this.GetDuration(index)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return 0
+	}
+	return v
+}
+func (this *JSONArray) OptBytes(index int, defaultvalue ...[]byte) []byte {
+	v, err := this.GetBytes(index)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return nil
+	}
+	return v
+}
 func (this *JSONArray) OptString(index int, defaultvalue ...string) string {
 	v, err := this.GetString(index)
 	if err != nil {
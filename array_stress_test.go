@@ -0,0 +1,274 @@
+package jsonlight
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// path of view from the root, false if view is detached
+func viewPath(a, root *JSONArray) ([]int, bool) {
+	var path []int
+	for x := a; x != root; x = x.a {
+		if !x.attached() {
+			return nil, false
+		}
+		path = append([]int{x.akey}, path...)
+	}
+	return path, true
+}
+
+func modelAt(model []interface{}, path []int) []interface{} {
+	for _, i := range path {
+		model = model[i].([]interface{})
+	}
+	return model
+}
+
+// random nested array reachable from root, both as view and as model path
+func randomView(r *rand.Rand, root *JSONArray) (*JSONArray, []int) {
+	cur, path := root, []int{}
+	for r.Intn(3) > 0 {
+		var nested []int
+		for i, v := range cur.ToSliceOrDie() {
+			if _, ok := v.([]interface{}); ok {
+				nested = append(nested, i)
+			}
+		}
+		if len(nested) == 0 {
+			break
+		}
+		i := nested[r.Intn(len(nested))]
+		next, err := cur.GetArray(i)
+		if err != nil {
+			panic(err)
+		}
+		cur, path = next.(*JSONArray), append(path, i)
+	}
+	return cur, path
+}
+
+func setModel(model *[]interface{}, path []int, s []interface{}) {
+	if len(path) == 0 {
+		*model = s
+		return
+	}
+	modelAt(*model, path[:len(path)-1])[path[len(path)-1]] = s
+}
+
+func TestArrayStress(t *testing.T) {
+	for seed := int64(1); seed <= 50; seed++ {
+		r := rand.New(rand.NewSource(seed))
+		root := NewArray()
+		model := []interface{}{}
+		seen := map[*JSONArray]bool{}
+
+		for step := 0; step < 200; step++ {
+			v, path := randomView(r, root)
+			seen[v] = true
+			m := modelAt(model, path)
+
+			switch op := r.Intn(10); {
+			case op == 0 || len(m) == 0:
+				n := float64(step)
+				if v.Append(n) != v {
+					t.Fatalf("seed %d step %d: Append returned another array", seed, step)
+				}
+				setModel(&model, path, append(m, n))
+			case op == 1:
+				// new detached array becomes a view of the slot
+				child := NewArrayFromSlice([]interface{}{float64(step)}).(*JSONArray)
+				v.Append(child)
+				setModel(&model, path, append(m, []interface{}{float64(step)}))
+				seen[child] = true
+			case op == 2:
+				i := r.Intn(len(m))
+				v.Remove(i)
+				setModel(&model, path, append(append([]interface{}{}, m[:i]...), m[i+1:]...))
+			case op == 3:
+				i := r.Intn(len(m))
+				v.Put(i, "s")
+				m[i] = "s"
+			case op == 4 && len(path) > 0:
+				v.DetachFromParent()
+				parent := modelAt(model, path[:len(path)-1])
+				i := path[len(path)-1]
+				setModel(&model, path[:len(path)-1], append(append([]interface{}{}, parent[:i]...), parent[i+1:]...))
+			case op == 5:
+				i := r.Intn(len(m) + 1)
+				v.Insert(i, float64(step), []interface{}{"i"})
+				ins := []interface{}{float64(step), []interface{}{"i"}}
+				setModel(&model, path, append(append(append([]interface{}{}, m[:i]...), ins...), m[i:]...))
+			case op == 6:
+				i, del := r.Intn(len(m)+1), r.Intn(3)
+				v.Splice(i, del, "x")
+				if del > len(m)-i {
+					del = len(m) - i
+				}
+				setModel(&model, path, append(append(append([]interface{}{}, m[:i]...), "x"), m[i+del:]...))
+			case op == 7:
+				v.Reverse()
+				for i, j := 0, len(m)-1; i < j; i, j = i+1, j-1 {
+					m[i], m[j] = m[j], m[i]
+				}
+			case op == 8 && r.Intn(10) == 0:
+				v.Clear()
+				setModel(&model, path, []interface{}{})
+			case op == 8:
+				i, j := r.Intn(len(m)), r.Intn(len(m))
+				v.Swap(i, j)
+				m[i], m[j] = m[j], m[i]
+			default:
+				// putting attached array stores a copy
+				other, opath := randomView(r, root)
+				i := r.Intn(len(m))
+				if _, err := v.Put(i, other); err != nil {
+					continue
+				}
+				m[i] = DeepCopy(modelAt(model, opath))
+			}
+
+			if !reflect.DeepEqual(root.ToSliceOrDie(), model) {
+				t.Fatalf("seed %d step %d:\n got %s\nwant %s", seed, step, root.ToString(), mustJSON(model))
+			}
+			for s := range seen {
+				if s.Length() < 0 || !json.Valid(s.ToByteArray()) {
+					t.Fatalf("seed %d step %d: view is unusable: %s", seed, step, s.ToString())
+				}
+				if p, ok := viewPath(s, root); ok {
+					if got := s.ToSliceOrDie(); !reflect.DeepEqual(got, modelAt(model, p)) {
+						t.Fatalf("seed %d step %d: view at %v is %s, want %s", seed, step, p, s.ToString(), mustJSON(modelAt(model, p)))
+					}
+				}
+			}
+		}
+	}
+}
+
+func mustJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func TestArrayViewSurvivesReallocation(t *testing.T) {
+	o := NewObjectOrDie(`{"a":[[1]]}`)
+	outer := o.OptArray("a")
+	inner := outer.OptArray(0)
+	for i := 0; i < 100; i++ {
+		outer.Append(i)
+		inner.Append(i)
+	}
+	if inner.Length() != 101 || outer.Length() != 101 {
+		t.Fatalf("lengths: inner %d, outer %d", inner.Length(), outer.Length())
+	}
+	if n := o.OptArray("a").OptArray(0).Length(); n != 101 {
+		t.Errorf("parent sees %d elements", n)
+	}
+	if outer.OptArray(0) != inner {
+		t.Errorf("expected the same view for the same element")
+	}
+}
+
+func TestArrayViewAfterParentRemoval(t *testing.T) {
+	outer := NewArrayOrDie(`[[1],[2],[3]]`)
+	first := outer.OptArray(0)
+	last := outer.OptArray(2)
+	outer.Remove(0)
+
+	// removed element keeps its contents and can be used on its own
+	if first.ToString() != `[1]` {
+		t.Errorf("removed view: %s", first.ToString())
+	}
+	first.Append(5)
+	if outer.ToString() != `[[2],[3]]` {
+		t.Errorf("removed view changed parent: %s", outer.ToString())
+	}
+
+	// view of the element after removed one still points to it
+	last.Append(4)
+	if outer.ToString() != `[[2],[3,4]]` {
+		t.Errorf("shifted view: %s", outer.ToString())
+	}
+}
+
+// views of the same key are separate, elements moved by one of them
+// must not leave nested views of another pointing to wrong element
+func TestArrayViewsOfSameKey(t *testing.T) {
+	o := NewObjectOrDie(`{"a":[[1],[2],[3]]}`)
+	a1, _ := o.GetArray("a")
+	a2, _ := o.GetArray("a")
+	n, _ := a2.GetArray(0)
+	a1.Remove(0)
+
+	if n.ToString() != `[1]` {
+		t.Errorf("view of removed element: %s", n.ToString())
+	}
+	n.Append(99)
+	if o.ToString() != `{"a":[[2],[3]]}` {
+		t.Errorf("view of removed element changed parent: %s", o.ToString())
+	}
+	if x := a2.OptArray(0); x == n || x.ToString() != `[2]` {
+		t.Errorf("new view expected for the element, got %s", x.ToString())
+	}
+	a2.OptArray(0).Append(4)
+	if o.ToString() != `{"a":[[2,4],[3]]}` {
+		t.Errorf("unexpected %s", o.ToString())
+	}
+}
+
+func TestArrayViewOfReplacedKey(t *testing.T) {
+	o := NewObjectOrDie(`{"a":[1]}`)
+	a := o.OptArray("a")
+	o.Put("a", "str")
+	if a.Length() != 1 || a.Append(2) == nil {
+		t.Fatalf("view is unusable after key replacement")
+	}
+	if o.OptString("a") != "str" {
+		t.Errorf("detached view wrote to parent: %s", o.ToString())
+	}
+	if a.ToString() != `[1,2]` {
+		t.Errorf("view contents: %s", a.ToString())
+	}
+}
+
+func TestNewArrayWritesBack(t *testing.T) {
+	var s []interface{}
+	a := NewArray(&s)
+	for i := 0; i < 10; i++ {
+		a.Append(i)
+	}
+	a.Remove(0)
+	if len(s) != 9 || s[0] != 1 {
+		t.Errorf("slice not updated: %v", s)
+	}
+}
+
+// reads do not change shared state, run with -race
+func TestArrayConcurrentReads(t *testing.T) {
+	o := NewObjectOrDie(`{"a":[[1],[2,[3]],[4]]}`)
+	a := o.OptArray("a")
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 300; i++ {
+				c, err := a.GetArray(i % 3)
+				if err != nil || c.Length() == 0 || a.Length() != 3 {
+					t.Errorf("unexpected %v %v", c, err)
+					return
+				}
+				if nested, err := c.GetArray(1); err == nil && nested.OptInt(0) != 3 {
+					t.Errorf("nested: %s", nested.ToString())
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if a.OptArray(1) != a.OptArray(1) {
+		t.Error("expected the same view for the same element")
+	}
+}
@@ -0,0 +1,124 @@
+package jsonlight
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewArrayFrom(t *testing.T) {
+	inputs := []interface{}{
+		`[1,"two",{"three":3}]`,
+		[]byte(`[1,"two",{"three":3}]`),
+		strings.NewReader(`[1,"two",{"three":3}]`),
+		[]interface{}{1.0, "two", map[string]interface{}{"three": 3.0}},
+	}
+	for _, in := range inputs {
+		a, err := NewArrayFrom(in)
+		if err != nil {
+			t.Fatalf("%T: %v", in, err)
+		}
+		if a.Length() != 3 {
+			t.Errorf("%T: expected 3 elements, got %d", in, a.Length())
+		}
+		if s := a.OptString(1); s != "two" {
+			t.Errorf("%T: expected two, got %q", in, s)
+		}
+		if v := a.OptObject(2).OptInt("three"); v != 3 {
+			t.Errorf("%T: expected 3, got %d", in, v)
+		}
+	}
+
+	if _, err := NewArrayFromString(`{"a":1}`); err == nil {
+		t.Error("object should not be parsed as array")
+	}
+	if a := NewArrayOrNil(42); a != nil {
+		t.Error("unsupported input should produce nil")
+	}
+}
+
+func TestArrayInsertSplice(t *testing.T) {
+	a := NewArrayOrDie(`[1,[2],3]`)
+	nested := a.OptArray(1)
+
+	if err := a.Insert(0, "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Insert(a.Length(), "z"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Insert(0, 1, struct{}{}); err == nil {
+		t.Error("unsupported value should be rejected")
+	}
+	if err := a.Insert(100, 1); err == nil {
+		t.Error("index out of range should be rejected")
+	}
+	nested.Append(2.5)
+	if s := a.ToString(); s != `["a","b",1,[2,2.5],3,"z"]` {
+		t.Errorf("after insert: %s", s)
+	}
+
+	removed, err := a.Splice(1, 2, "x")
+	if err != nil || len(removed) != 2 || removed[0] != "b" {
+		t.Fatalf("removed %v, %v", removed, err)
+	}
+	nested.Append(2.75)
+	if s := a.ToString(); s != `["a","x",[2,2.5,2.75],3,"z"]` {
+		t.Errorf("after splice: %s", s)
+	}
+
+	a.Splice(2, 100)
+	if s := a.ToString(); s != `["a","x"]` {
+		t.Errorf("after splice to the end: %s", s)
+	}
+	if nested.Append(4); a.Length() != 2 || nested.ToString() != `[2,2.5,2.75,4]` {
+		t.Errorf("removed view should keep its contents: %s", nested.ToString())
+	}
+}
+
+func TestArraySearchAndReorder(t *testing.T) {
+	a := NewArrayOrDie(`[1,{"a":[1]},"s",1,[2]]`)
+
+	if i := a.IndexOf(1); i != 0 {
+		t.Errorf("IndexOf(1) = %d", i)
+	}
+	if i := a.LastIndexOf(int64(1)); i != 3 {
+		t.Errorf("LastIndexOf(1) = %d", i)
+	}
+	if i := a.IndexOf(NewObjectOrDie(`{"a":[1.0]}`)); i != 1 {
+		t.Errorf("IndexOf(object) = %d", i)
+	}
+	if !a.Contains([]interface{}{2}) || a.Contains("x") {
+		t.Error("Contains is wrong")
+	}
+
+	nested := a.OptArray(4)
+	a.Reverse()
+	nested.Append(3)
+	if s := a.ToString(); s != `[[2,3],1,"s",{"a":[1]},1]` {
+		t.Errorf("after reverse: %s", s)
+	}
+	if err := a.Swap(0, 4); err != nil {
+		t.Fatal(err)
+	}
+	nested.Append(4)
+	if s := a.ToString(); s != `[1,1,"s",{"a":[1]},[2,3,4]]` {
+		t.Errorf("after swap: %s", s)
+	}
+	if a.Swap(0, 5) == nil {
+		t.Error("swap out of range should fail")
+	}
+
+	if s := a.Slice(-2, 100).ToString(); s != `[{"a":[1]},[2,3,4]]` {
+		t.Errorf("slice: %s", s)
+	}
+	c := a.Concat(NewArrayOrDie(`[5]`), nil)
+	c.OptArray(4).Append(0)
+	if c.Length() != 6 || a.OptArray(4).Length() != 3 {
+		t.Errorf("concat should copy: %s, %s", c.ToString(), a.ToString())
+	}
+
+	a.Clear()
+	if a.Length() != 0 || nested.Length() != 3 {
+		t.Errorf("after clear: %s, %s", a.ToString(), nested.ToString())
+	}
+}
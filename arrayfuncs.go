@@ -0,0 +1,183 @@
+package jsonlight
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// callbacks get objects as IObject and arrays as IArray, other values as is.
+// nested array is a live view only if it was taken with GetArray before,
+// otherwise it is a detached array over the element, good for reading,
+// so iterating does not leave registered views behind.
+// array should not be changed structurally while it is iterated
+
+func (this *JSONArray) elem(index int, v interface{}) interface{} {
+	if o, ok := ObjectValue(v); ok {
+		return o
+	}
+	if s, ok := v.([]interface{}); ok {
+		if c := this.child(index); c != nil && c.attached() {
+			return c
+		}
+		return &JSONArray{data: s}
+	}
+	return v
+}
+
+func (this *JSONArray) ForEach(fn func(index int, value interface{})) {
+	for i, v := range this.resolve() {
+		fn(i, this.elem(i, v))
+	}
+}
+
+// new detached array with copies of values returned by fn
+func (this *JSONArray) Map(fn func(index int, value interface{}) interface{}) (IArray, error) {
+	s := this.resolve()
+	res := &JSONArray{data: make([]interface{}, len(s))}
+	for i, v := range s {
+		if _, err := res.Put(i, DeepCopy(fn(i, this.elem(i, v)))); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// new detached array with copies of elements for which fn returns true
+func (this *JSONArray) Filter(fn func(index int, value interface{}) bool) IArray {
+	res := make([]interface{}, 0)
+	for i, v := range this.resolve() {
+		if fn(i, this.elem(i, v)) {
+			res = append(res, DeepCopy(v))
+		}
+	}
+	return NewArrayFromSlice(res)
+}
+
+func (this *JSONArray) Find(fn func(index int, value interface{}) bool) (interface{}, bool) {
+	if i := this.FindIndex(fn); i >= 0 {
+		return this.elem(i, this.resolve()[i]), true
+	}
+	return nil, false
+}
+
+// index of first element for which fn returns true, or -1
+func (this *JSONArray) FindIndex(fn func(index int, value interface{}) bool) int {
+	for i, v := range this.resolve() {
+		if fn(i, this.elem(i, v)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// false for empty array
+func (this *JSONArray) Any(fn func(index int, value interface{}) bool) bool {
+	return this.FindIndex(fn) >= 0
+}
+
+// true for empty array
+func (this *JSONArray) Every(fn func(index int, value interface{}) bool) bool {
+	return this.FindIndex(func(i int, v interface{}) bool { return !fn(i, v) }) < 0
+}
+
+func (this *JSONArray) Reduce(fn func(acc interface{}, index int, value interface{}) interface{}, initial interface{}) interface{} {
+	acc := initial
+	for i, v := range this.resolve() {
+		acc = fn(acc, i, this.elem(i, v))
+	}
+	return acc
+}
+
+// ordered object: group key -> array with copies of elements, groups go in order of first appearance
+func (this *JSONArray) GroupBy(keyFn func(index int, value interface{}) string) IObject {
+	res := NewOrderedObject()
+	for i, v := range this.resolve() {
+		k := keyFn(i, this.elem(i, v))
+		g, err := res.GetArray(k)
+		if err != nil {
+			g = NewArray()
+			res.Put(k, g)
+		}
+		g.Append(DeepCopy(v))
+	}
+	return res
+}
+
+// stable in-place sort. key_or_comparator is one of:
+//   - field name, or JSON pointer starting with "/", elements are ordered by that field
+//     using CompareValues; "-" prefix sorts in descending order
+//   - func(a, b interface{}) int, cmp-like
+//   - func(a, b interface{}) bool, less-like
+func (this *JSONArray) SortBy(key_or_comparator interface{}) error {
+	s := this.resolve()
+	vals := make([]interface{}, len(s))
+	var cmp func(a, b interface{}) int
+
+	switch k := key_or_comparator.(type) {
+	case string:
+		desc := strings.HasPrefix(k, "-")
+		if desc {
+			k = k[1:]
+		}
+		for i, v := range s {
+			if o, ok := ReadonlyObjectValue(v); ok {
+				if strings.HasPrefix(k, "/") {
+					vals[i], _ = o.GetPointer(k)
+				} else {
+					vals[i], _ = o.Get(k)
+				}
+			}
+		}
+		cmp = CompareValues
+		if desc {
+			cmp = func(a, b interface{}) int { return CompareValues(b, a) }
+		}
+	case func(a, b interface{}) int:
+		cmp = k
+	case func(a, b interface{}) bool:
+		cmp = func(a, b interface{}) int {
+			if k(a, b) {
+				return -1
+			}
+			if k(b, a) {
+				return 1
+			}
+			return 0
+		}
+	default:
+		return fmt.Errorf("SortBy: unsupported key %T", key_or_comparator)
+	}
+	if _, byKey := key_or_comparator.(string); !byKey {
+		for i, v := range s {
+			vals[i] = this.elem(i, v)
+		}
+	}
+
+	perm := make([]int, len(s))
+	for i := range perm {
+		perm[i] = i
+	}
+	sort.SliceStable(perm, func(x, y int) bool {
+		return cmp(vals[perm[x]], vals[perm[y]]) < 0
+	})
+	this.reorder(perm)
+	return nil
+}
+
+// element perm[i] goes to position i, views follow their elements
+func (this *JSONArray) reorder(perm []int) {
+	s := this.resolve()
+	old := append([]interface{}(nil), s...)
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	moved := make(map[int]*JSONArray, len(this.children))
+	for i, p := range perm {
+		s[i] = old[p]
+		if c := this.children[p]; c != nil {
+			c.akey = i
+			moved[i] = c
+		}
+	}
+	this.children = moved
+}
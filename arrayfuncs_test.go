@@ -0,0 +1,142 @@
+package jsonlight
+
+import (
+	"strings"
+	"testing"
+)
+
+const users = `[
+	{"name":"bob","age":30,"team":"a"},
+	{"name":"alice","age":25,"team":"b"},
+	{"name":"carol","team":"a"},
+	{"name":"dave","age":25,"team":"a","tags":["x"]}
+]`
+
+func TestArrayFuncs(t *testing.T) {
+	a := NewArrayOrDie(users)
+
+	names := []string{}
+	a.ForEach(func(i int, v interface{}) {
+		names = append(names, v.(IObject).OptString("name"))
+	})
+	if strings.Join(names, ",") != "bob,alice,carol,dave" {
+		t.Errorf("ForEach: %v", names)
+	}
+
+	m, err := a.Map(func(i int, v interface{}) interface{} { return v.(IObject).OptString("name") })
+	if err != nil || m.ToString() != `["bob","alice","carol","dave"]` {
+		t.Errorf("Map: %v, %v", m, err)
+	}
+	if _, err := a.Map(func(i int, v interface{}) interface{} { return struct{}{} }); err == nil {
+		t.Error("Map should fail on unsupported values")
+	}
+
+	young := a.Filter(func(i int, v interface{}) bool { return v.(IObject).OptInt("age", 100) < 30 })
+	if young.Length() != 2 {
+		t.Fatalf("Filter: %s", young.ToString())
+	}
+	young.OptObject(0).Put("name", "changed")
+	if a.OptObject(1).OptString("name") != "alice" {
+		t.Error("Filter should copy elements")
+	}
+
+	v, ok := a.Find(func(i int, v interface{}) bool { return !v.(IObject).Has("age") })
+	if !ok || v.(IObject).OptString("name") != "carol" {
+		t.Errorf("Find: %v", v)
+	}
+	if i := a.FindIndex(func(i int, v interface{}) bool { return false }); i != -1 {
+		t.Errorf("FindIndex: %d", i)
+	}
+	if !a.Any(func(i int, v interface{}) bool { return v.(IObject).Has("tags") }) {
+		t.Error("Any")
+	}
+	if a.Every(func(i int, v interface{}) bool { return v.(IObject).Has("age") }) {
+		t.Error("Every")
+	}
+	if !NewEmptyArray().Every(func(i int, v interface{}) bool { return false }) {
+		t.Error("Every on empty array")
+	}
+
+	total := a.Reduce(func(acc interface{}, i int, v interface{}) interface{} {
+		return acc.(int) + v.(IObject).OptInt("age")
+	}, 0)
+	if total != 80 {
+		t.Errorf("Reduce: %v", total)
+	}
+
+	groups := a.GroupBy(func(i int, v interface{}) string { return v.(IObject).OptString("team") })
+	if s := strings.Join(groups.Keys(), ","); s != "a,b" {
+		t.Errorf("group order: %s", s)
+	}
+	if n := groups.OptArray("a").Length(); n != 3 {
+		t.Errorf("group a has %d elements", n)
+	}
+
+	// iterating does not register views of nested arrays
+	nested := NewArrayOrDie(`[[1],[2,3]]`).(*JSONArray)
+	sum := nested.Reduce(func(acc interface{}, i int, v interface{}) interface{} {
+		return acc.(int) + v.(IArray).Length()
+	}, 0)
+	if sum != 3 || len(nested.children) != 0 {
+		t.Errorf("Reduce over nested arrays: %v, %d views", sum, len(nested.children))
+	}
+	view := nested.OptArray(1)
+	nested.ForEach(func(i int, v interface{}) {
+		if i == 1 && v != view {
+			t.Error("existing view expected")
+		}
+	})
+}
+
+func TestArraySortBy(t *testing.T) {
+	names := func(a IArray) string {
+		m, _ := a.Map(func(i int, v interface{}) interface{} { return v.(IObject).OptString("name") })
+		return m.Join(",")
+	}
+
+	a := NewArrayOrDie(users)
+	tags := a.OptObject(3).OptArray("tags")
+	if err := a.SortBy("age"); err != nil {
+		t.Fatal(err)
+	}
+	// missing age is null and goes first, equal ages keep their order
+	if s := names(a); s != "carol,alice,dave,bob" {
+		t.Errorf("by age: %s", s)
+	}
+	a.SortBy("-name")
+	if s := names(a); s != "dave,carol,bob,alice" {
+		t.Errorf("by name desc: %s", s)
+	}
+	a.SortBy("/tags/0")
+	if s := names(a); s != "carol,bob,alice,dave" {
+		t.Errorf("by pointer: %s", s)
+	}
+	tags.Append("y")
+	if s := a.OptObject(3).OptArray("tags").Join(","); s != "x,y" {
+		t.Errorf("object should stay live after sort: %s", s)
+	}
+
+	err := a.SortBy(func(x, y interface{}) bool {
+		return len(x.(IObject).OptString("name")) < len(y.(IObject).OptString("name"))
+	})
+	if err != nil || names(a) != "bob,dave,carol,alice" {
+		t.Errorf("by comparator: %s, %v", names(a), err)
+	}
+	if a.SortBy(42) == nil {
+		t.Error("unsupported key should fail")
+	}
+
+	mixed := NewArrayOrDie(`[true,"b",[1],{"a":1},2,null,"a",1.5,false,[0,1]]`)
+	mixed.SortBy(CompareValues)
+	if s := mixed.ToString(); s != `[null,1.5,2,"a","b",{"a":1},[0,1],[1],false,true]` {
+		t.Errorf("mixed: %s", s)
+	}
+
+	nested := NewArrayOrDie(`[[3],[1],[2]]`)
+	first := nested.OptArray(0)
+	nested.SortBy(CompareValues)
+	first.Append(4)
+	if s := nested.ToString(); s != `[[1],[2],[3,4]]` {
+		t.Errorf("views should follow sorted elements: %s", s)
+	}
+}
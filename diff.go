@@ -0,0 +1,185 @@
+package jsonlight
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// single difference between two documents.
+// Path is JSON Pointer, Old is nil for added values, New is nil for removed ones
+type Change struct {
+	Path string
+	Kind ChangeKind
+	Old  interface{}
+	New  interface{}
+}
+
+func (this Change) String() string {
+	switch this.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("added %s: %s", this.Path, jsonString(this.New))
+	case ChangeRemoved:
+		return fmt.Sprintf("removed %s: %s", this.Path, jsonString(this.Old))
+	}
+	return fmt.Sprintf("modified %s: %s -> %s", this.Path, jsonString(this.Old), jsonString(this.New))
+}
+
+func jsonString(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
+
+type DiffOptions struct {
+	// if set, arrays of objects are matched by this member (e.g. "id") instead of index.
+	// arrays where some element has no such member or it is not unique are compared by index
+	ArrayKey string
+}
+
+// compares documents recursively and returns changed leaves.
+// numbers are compared by value, so 1 and 1.0 are equal
+func Diff(oldo IReadonlyObject, newo IReadonlyObject, opts ...DiffOptions) []Change {
+	d := &differ{changes: make([]Change, 0)}
+	if len(opts) > 0 {
+		d.opts = opts[0]
+	}
+	d.diff("", oldo, newo)
+	return d.changes
+}
+
+type differ struct {
+	opts    DiffOptions
+	changes []Change
+}
+
+func (this *differ) add(path string, kind ChangeKind, oldv, newv interface{}) {
+	this.changes = append(this.changes, Change{Path: path, Kind: kind, Old: oldv, New: newv})
+}
+
+func (this *differ) diff(path string, a, b interface{}) {
+	if ValuesEqual(a, b) {
+		return
+	}
+
+	ao, aok := ReadonlyObjectValue(a)
+	bo, bok := ReadonlyObjectValue(b)
+	if aok && bok {
+		for _, k := range stableKeys(ao) {
+			if !bo.Has(k) {
+				v, _ := ao.Get(k)
+				this.add(path+"/"+EscapePointerToken(k), ChangeRemoved, v, nil)
+			}
+		}
+		for _, k := range stableKeys(bo) {
+			bv, _ := bo.Get(k)
+			if av, ok := ao.Get(k); ok {
+				this.diff(path+"/"+EscapePointerToken(k), av, bv)
+			} else {
+				this.add(path+"/"+EscapePointerToken(k), ChangeAdded, nil, bv)
+			}
+		}
+		return
+	}
+
+	as, aok := SliceValue(a)
+	bs, bok := SliceValue(b)
+	if aok && bok {
+		if !this.diffArraysByKey(path, as, bs) {
+			this.diffArraysByIndex(path, as, bs)
+		}
+		return
+	}
+
+	this.add(path, ChangeModified, a, b)
+}
+
+func (this *differ) diffArraysByIndex(path string, as, bs []interface{}) {
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		this.diff(path+"/"+strconv.Itoa(i), as[i], bs[i])
+	}
+	for i := len(bs); i < len(as); i++ {
+		this.add(path+"/"+strconv.Itoa(i), ChangeRemoved, as[i], nil)
+	}
+	for i := len(as); i < len(bs); i++ {
+		this.add(path+"/"+strconv.Itoa(i), ChangeAdded, nil, bs[i])
+	}
+}
+
+// removed elements get their old index in path, others get the new one
+func (this *differ) diffArraysByKey(path string, as, bs []interface{}) bool {
+	if this.opts.ArrayKey == "" {
+		return false
+	}
+	aindex, aok := this.indexByKey(as)
+	bindex, bok := this.indexByKey(bs)
+	if !aok || !bok {
+		return false
+	}
+
+	for i, v := range as {
+		if _, ok := bindex[arrayKeyOf(v, this.opts.ArrayKey)]; !ok {
+			this.add(path+"/"+strconv.Itoa(i), ChangeRemoved, v, nil)
+		}
+	}
+	for i, v := range bs {
+		if j, ok := aindex[arrayKeyOf(v, this.opts.ArrayKey)]; ok {
+			this.diff(path+"/"+strconv.Itoa(i), as[j], v)
+		} else {
+			this.add(path+"/"+strconv.Itoa(i), ChangeAdded, nil, v)
+		}
+	}
+	return true
+}
+
+// key value -> index, fails if some element is not an object with unique scalar key
+func (this *differ) indexByKey(s []interface{}) (map[string]int, bool) {
+	res := make(map[string]int, len(s))
+	for i, v := range s {
+		k := arrayKeyOf(v, this.opts.ArrayKey)
+		if k == "" {
+			return nil, false
+		}
+		if _, dup := res[k]; dup {
+			return nil, false
+		}
+		res[k] = i
+	}
+	return res, true
+}
+
+// string representation of key member, empty if there is no usable key.
+// type is part of the result so that 1 and "1" are different keys
+func arrayKeyOf(v interface{}, key string) string {
+	o, ok := ReadonlyObjectValue(v)
+	if !ok {
+		return ""
+	}
+	k, ok := o.Get(key)
+	if !ok {
+		return ""
+	}
+	switch kk := k.(type) {
+	case string:
+		return "s" + kk
+	case bool:
+		return "b" + strconv.FormatBool(kk)
+	}
+	if b, ok := BigIntValue(k); ok {
+		return "n" + b.String()
+	}
+	if f, ok := NumberValue(k); ok {
+		return "n" + strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return ""
+}
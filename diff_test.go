@@ -0,0 +1,45 @@
+package jsonlight
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	oldo := NewObjectOrDie(`{"a":{"b":{"c":1,"d":2}},"n":1,"list":[1,2,3],"gone":true}`)
+	newo := NewObjectOrDie(`{"a":{"b":{"c":1,"d":3}},"n":1.0,"list":[1,5],"new":"x"}`)
+
+	changes := Diff(oldo, newo)
+	expected := []string{
+		`removed /gone: true`,
+		`modified /a/b/d: 2 -> 3`,
+		`modified /list/1: 2 -> 5`,
+		`removed /list/2: 3`,
+		`added /new: "x"`,
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("unexpected changes %v", changes)
+	}
+	for i, c := range changes {
+		if c.String() != expected[i] {
+			t.Errorf("expected %s, got %s", expected[i], c)
+		}
+	}
+}
+
+func TestDiffArrayKey(t *testing.T) {
+	oldo := NewObjectOrDie(`{"items":[{"id":1,"v":"a"},{"id":2,"v":"b"},{"id":3,"v":"c"}]}`)
+	newo := NewObjectOrDie(`{"items":[{"id":3,"v":"c"},{"id":1,"v":"z"},{"id":4,"v":"d"}]}`)
+
+	changes := Diff(oldo, newo, DiffOptions{ArrayKey: "id"})
+	expected := []Change{
+		{Path: "/items/1", Kind: ChangeRemoved},
+		{Path: "/items/1/v", Kind: ChangeModified},
+		{Path: "/items/2", Kind: ChangeAdded},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("unexpected changes %v", changes)
+	}
+	for i, c := range changes {
+		if c.Path != expected[i].Path || c.Kind != expected[i].Kind {
+			t.Errorf("expected %s %s, got %s", expected[i].Kind, expected[i].Path, c)
+		}
+	}
+}
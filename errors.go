@@ -0,0 +1,210 @@
+package jsonlight
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// accessor errors carry location of the value:
+// Key is string for objects and int for arrays (nil if unknown),
+// Path is JSON Pointer relative to the object the call was made on:
+// arrays remember where they were taken from, so o.GetArray("a").GetLong(1) reports /a/1,
+// objects do not, so o.GetObject("a").GetLong("x") reports /x.
+// pointer accessors report full pointer, e.g. o.GetLongPointer("/a/x") reports /a/x.
+// errors.Is matches errors of the same type regardless of details,
+// use errors.As to read them
+
+type NotFoundError struct {
+	Key  interface{}
+	Path string
+}
+
+type NilConvertError struct {
+	Key      interface{}
+	Path     string
+	Expected string
+}
+
+// Expected is json kind like "integer" or "object", or go type for generic accessors.
+// Actual is json kind of the value, ActualType is its go type
+type TypeConvertError struct {
+	Key        interface{}
+	Path       string
+	Expected   string
+	Actual     string
+	ActualType string
+}
+
+// not returned anymore, arrays detach instead of expiring
+type ArrayExpiredError struct{}
+
+// rejected Put: unsupported or nil value, self reference, bad index.
+// Err is set if there is more specific cause, e.g. NotFoundError
+type PutError struct {
+	Key       interface{}
+	ValueType string
+	Reason    string
+	Err       error
+}
+
+// returned by reader-based constructors when input is bigger than allowed
+type TooLargeError struct {
+	Limit int64
+}
+
+func at(path string) string {
+	if path == "" {
+		return ""
+	}
+	return " at " + path
+}
+
+func (a NotFoundError) Error() string {
+	return "Element not found" + at(a.Path)
+}
+
+func (a NilConvertError) Error() string {
+	if a.Expected == "" {
+		return "Nil convertion error" + at(a.Path)
+	}
+	return fmt.Sprintf("Nil convertion error%s: expected %s", at(a.Path), a.Expected)
+}
+
+func (a TypeConvertError) Error() string {
+	if a.Expected == "" {
+		return "Type convertion error" + at(a.Path)
+	}
+	return fmt.Sprintf("Type convertion error%s: expected %s, got %s (%s)", at(a.Path), a.Expected, a.Actual, a.ActualType)
+}
+
+func (a ArrayExpiredError) Error() string { return "Array expired" }
+
+func (a PutError) Error() string {
+	var buf strings.Builder
+	buf.WriteString("Put failed")
+	if a.Key != nil {
+		buf.WriteString(at(keyPath(a.Key)))
+	}
+	buf.WriteString(": " + a.Reason)
+	if a.ValueType != "" {
+		buf.WriteString(" (" + a.ValueType + ")")
+	}
+	if a.Err != nil {
+		buf.WriteString(": " + a.Err.Error())
+	}
+	return buf.String()
+}
+
+func (a PutError) Unwrap() error { return a.Err }
+
+func (a TooLargeError) Error() string {
+	return fmt.Sprintf("Input exceeds size limit of %d bytes", a.Limit)
+}
+
+func (a NotFoundError) Is(target error) bool {
+	_, ok := target.(NotFoundError)
+	return ok
+}
+
+func (a NilConvertError) Is(target error) bool {
+	_, ok := target.(NilConvertError)
+	return ok
+}
+
+func (a TypeConvertError) Is(target error) bool {
+	_, ok := target.(TypeConvertError)
+	return ok
+}
+
+func (a PutError) Is(target error) bool {
+	_, ok := target.(PutError)
+	return ok
+}
+
+func (a TooLargeError) Is(target error) bool {
+	_, ok := target.(TooLargeError)
+	return ok
+}
+
+//-------------------------------------
+
+func keyPath(key interface{}) string {
+	switch k := key.(type) {
+	case string:
+		return "/" + EscapePointerToken(k)
+	case int:
+		return "/" + strconv.Itoa(k)
+	}
+	return ""
+}
+
+// json kind of value, like in JSON Schema but without "integer"
+func kindOf(v interface{}) string {
+	if isNil(&v) {
+		return "null"
+	}
+	switch v.(type) {
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	}
+	if _, ok := NumberValue(v); ok {
+		return "number"
+	}
+	if _, ok := BigIntValue(v); ok {
+		return "number"
+	}
+	if _, ok := ReadonlyObjectValue(v); ok {
+		return "object"
+	}
+	if _, ok := SliceValue(v); ok {
+		return "array"
+	}
+	if _, ok := v.(IArray); ok {
+		return "array"
+	}
+	return "unknown"
+}
+
+func notFound(key interface{}) error {
+	return NotFoundError{Key: key, Path: keyPath(key)}
+}
+
+func nilValue(key interface{}, expected string) error {
+	return NilConvertError{Key: key, Path: keyPath(key), Expected: expected}
+}
+
+func typeMismatch(key interface{}, expected string, v interface{}) error {
+	return TypeConvertError{Key: key, Path: keyPath(key), Expected: expected, Actual: kindOf(v), ActualType: fmt.Sprintf("%T", v)}
+}
+
+// location for errors made by value-level helpers, which know nothing about containers
+func errorAtKey(err error, key interface{}) error {
+	switch e := err.(type) {
+	case NilConvertError:
+		e.Key, e.Path = key, keyPath(key)
+		return e
+	case TypeConvertError:
+		e.Key, e.Path = key, keyPath(key)
+		return e
+	}
+	return err
+}
+
+// prepends path of the container to location of accessor error
+func locateError(err error, prefix string) error {
+	switch e := err.(type) {
+	case NotFoundError:
+		e.Path = prefix + e.Path
+		return e
+	case NilConvertError:
+		e.Path = prefix + e.Path
+		return e
+	case TypeConvertError:
+		e.Path = prefix + e.Path
+		return e
+	}
+	return err
+}
@@ -0,0 +1,76 @@
+package jsonlight
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAccessorErrors(t *testing.T) {
+	o := NewObjectOrDie(`{"a":{"list":[1,"x",null]},"s":"str"}`)
+
+	_, err := o.GetLong("s")
+	var tce TypeConvertError
+	if !errors.As(err, &tce) || tce.Key != "s" || tce.Path != "/s" || tce.Expected != "integer" || tce.Actual != "string" {
+		t.Errorf("unexpected error %#v", err)
+	}
+	if err.Error() != "Type convertion error at /s: expected integer, got string (string)" {
+		t.Errorf("unexpected message %s", err)
+	}
+
+	_, err = o.GetLongPointer("/a/list/1")
+	if !errors.As(err, &tce) || tce.Key != 1 || tce.Path != "/a/list/1" {
+		t.Errorf("unexpected error %#v", err)
+	}
+	_, err = o.GetStringPointer("/a/list/2")
+	var nce NilConvertError
+	if !errors.As(err, &nce) || nce.Path != "/a/list/2" || nce.Expected != "string" {
+		t.Errorf("unexpected error %#v", err)
+	}
+	_, err = o.GetStringPointer("/a/missing/x")
+	var nfe NotFoundError
+	if !errors.As(err, &nfe) || nfe.Key != "missing" || nfe.Path != "/a/missing" {
+		t.Errorf("unexpected error %#v", err)
+	}
+	if !errors.Is(err, NotFoundError{}) || errors.Is(err, TypeConvertError{}) {
+		t.Error("errors.Is should match by type only")
+	}
+
+	// objects taken with GetObject report paths from themselves,
+	// arrays remember where they were taken from
+	_, err = o.OptObject("a").GetLong("missing")
+	if !errors.As(err, &nfe) || nfe.Key != "missing" || nfe.Path != "/missing" {
+		t.Errorf("unexpected error %#v", err)
+	}
+	_, err = o.OptObject("a").OptArray("list").GetLong(1)
+	if !errors.As(err, &tce) || tce.Path != "/list/1" {
+		t.Errorf("unexpected error %#v", err)
+	}
+
+	nested := NewObjectOrDie(`{"a":{"b":[[{"x":"str"}]]}}`)
+	_, err = nested.OptObject("a").OptArray("b").OptArray(0).GetLong(0)
+	if !errors.As(err, &tce) || tce.Path != "/b/0/0" {
+		t.Errorf("unexpected error %#v", err)
+	}
+	_, err = nested.GetLongPointer("/a/b/0/0/x")
+	if !errors.As(err, &tce) || tce.Path != "/a/b/0/0/x" {
+		t.Errorf("unexpected error %#v", err)
+	}
+	_, err = nested.GetStringPointer("/a/b/0/1")
+	if !errors.As(err, &nfe) || nfe.Path != "/a/b/0/1" {
+		t.Errorf("unexpected error %#v", err)
+	}
+	_, err = nested.OptArrayPointer("/a/b").GetLongPointer("/0/0/x")
+	if !errors.As(err, &tce) || tce.Path != "/b/0/0/x" {
+		t.Errorf("unexpected error %#v", err)
+	}
+
+	_, err = o.Put("x", struct{}{})
+	var pe PutError
+	if !errors.As(err, &pe) || pe.Key != "x" || pe.ValueType != "struct {}" {
+		t.Errorf("unexpected error %#v", err)
+	}
+	a := NewEmptyArray()
+	if _, err = a.Put(3, 1); !errors.Is(err, PutError{}) || !errors.Is(err, NotFoundError{}) {
+		t.Errorf("unexpected error %#v", err)
+	}
+}
@@ -1,14 +1,16 @@
 package jsonlight
 
 import (
-	"bytes"
-	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
 )
 
+// compares top-level keys only, see Diff for recursive comparison
 func CompareObjects(oldo IObject, newo IObject) (IObject, IObject, IObject, IObject) {
 	deleted, created, modified, unchanged := NewObjectOrNil(), NewObjectOrNil(), NewObjectOrNil(), NewObjectOrNil()
 
-	left := make([]string, newo.Length())
+	left := make([]string, 0, newo.Length())
 
 	for k, v := range oldo.ToMap() {
 		if !newo.Has(k) {
@@ -25,9 +27,7 @@ func CompareObjects(oldo IObject, newo IObject) (IObject, IObject, IObject, IObj
 	for _, i := range left {
 		oldval, _ := oldo.Get(i)
 		newval, _ := newo.Get(i)
-		joldval, _ := json.Marshal(oldval)
-		jnewval, _ := json.Marshal(newval)
-		if bytes.Compare(joldval, jnewval) != 0 {
+		if !ValuesEqual(oldval, newval) {
 			modified.Put(i, newval)
 		} else {
 			unchanged.Put(i, newval)
@@ -36,3 +36,188 @@ func CompareObjects(oldo IObject, newo IObject) (IObject, IObject, IObject, IObj
 
 	return deleted, created, modified, unchanged
 }
+
+// compares values the way JSON sees them:
+// numbers are compared by value (1 equals 1.0), objects and arrays are compared deeply
+func ValuesEqual(a, b interface{}) bool {
+	if isNil(&a) || isNil(&b) {
+		return isNil(&a) && isNil(&b)
+	}
+	if ao, ok := ReadonlyObjectValue(a); ok {
+		bo, ok := ReadonlyObjectValue(b)
+		if !ok || ao.Length() != bo.Length() {
+			return false
+		}
+		for _, k := range ao.Keys() {
+			av, _ := ao.Get(k)
+			bv, ok := bo.Get(k)
+			if !ok || !ValuesEqual(av, bv) {
+				return false
+			}
+		}
+		return true
+	}
+	if as, ok := SliceValue(a); ok {
+		bs, ok := SliceValue(b)
+		if !ok || len(as) != len(bs) {
+			return false
+		}
+		for i := range as {
+			if !ValuesEqual(as[i], bs[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if af, ok := NumberValue(a); ok {
+		bf, ok := NumberValue(b)
+		if !ok {
+			return false
+		}
+		// exact comparison of integers, floats lose precision above 2^53
+		ai, aok := BigIntValue(a)
+		bi, bok := BigIntValue(b)
+		if aok && bok {
+			return ai.Cmp(bi) == 0
+		}
+		return af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// copies objects and arrays recursively, other values are returned as is
+func DeepCopy(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(vv))
+		for k, x := range vv {
+			m[k] = DeepCopy(x)
+		}
+		return m
+	case JSONObject:
+		return JSONObject(DeepCopy(map[string]interface{}(vv)).(map[string]interface{}))
+	case *JSONObject:
+		if vv == nil {
+			return nil
+		}
+		c := JSONObject(DeepCopy(map[string]interface{}(*vv)).(map[string]interface{}))
+		return &c
+	case *OrderedObject:
+		if vv == nil {
+			return nil
+		}
+		c := &OrderedObject{JSONObject: make(JSONObject, vv.Length())}
+		for _, k := range vv.Keys() {
+			c.JSONObject[k] = DeepCopy(vv.JSONObject[k])
+			c.keys = append(c.keys, k)
+		}
+		return c
+	case []interface{}:
+		s := make([]interface{}, len(vv))
+		for i, x := range vv {
+			s[i] = DeepCopy(x)
+		}
+		return s
+	case *JSONArray, JSONArray:
+		if s, ok := SliceValue(vv); ok {
+			return DeepCopy(s)
+		}
+		return nil
+	}
+	return v
+}
+
+// orders values of any JSON types: null < numbers < strings < objects < arrays < booleans.
+// numbers are compared by value, arrays element by element, objects key by key in sorted order.
+// values of other go types are equal to each other and go last
+func CompareValues(a, b interface{}) int {
+	ra, rb := typeRank(a), typeRank(b)
+	if ra != rb {
+		return compareInts(ra, rb)
+	}
+	switch ra {
+	case 1:
+		ai, aok := BigIntValue(a)
+		bi, bok := BigIntValue(b)
+		if aok && bok {
+			return ai.Cmp(bi)
+		}
+		af, _ := NumberValue(a)
+		bf, _ := NumberValue(b)
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+	case 2:
+		return strings.Compare(a.(string), b.(string))
+	case 3:
+		ao, _ := ReadonlyObjectValue(a)
+		bo, _ := ReadonlyObjectValue(b)
+		ak, bk := ao.Keys(), bo.Keys()
+		sort.Strings(ak)
+		sort.Strings(bk)
+		for i := 0; i < len(ak) && i < len(bk); i++ {
+			if c := strings.Compare(ak[i], bk[i]); c != 0 {
+				return c
+			}
+			av, _ := ao.Get(ak[i])
+			bv, _ := bo.Get(bk[i])
+			if c := CompareValues(av, bv); c != 0 {
+				return c
+			}
+		}
+		return compareInts(len(ak), len(bk))
+	case 4:
+		as, _ := SliceValue(a)
+		bs, _ := SliceValue(b)
+		for i := 0; i < len(as) && i < len(bs); i++ {
+			if c := CompareValues(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+		return compareInts(len(as), len(bs))
+	case 5:
+		if a.(bool) == b.(bool) {
+			return 0
+		}
+		if b.(bool) {
+			return -1
+		}
+		return 1
+	}
+	return 0
+}
+
+func typeRank(v interface{}) int {
+	if isNil(&v) {
+		return 0
+	}
+	if _, ok := v.(string); ok {
+		return 2
+	}
+	if _, ok := v.(bool); ok {
+		return 5
+	}
+	if _, ok := NumberValue(v); ok {
+		return 1
+	}
+	if _, ok := ReadonlyObjectValue(v); ok {
+		return 3
+	}
+	if _, ok := SliceValue(v); ok {
+		return 4
+	}
+	return 6
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,269 @@
+package jsonlight
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"time"
+)
+
+// converts json value to T. numbers follow IntValue/FloatValue rules, so fractions
+// are truncated for integer types, and values out of the type's range fail,
+// time.Time, time.Duration and []byte follow TimeValue, DurationValue and BytesValue,
+// IObject, IReadonlyObject and IArray wrap the value without copying,
+// structs, maps and slices are filled like in FillStruct
+func As[T any](v interface{}) (T, error) {
+	var res T
+	expected := reflect.TypeOf(&res).Elem()
+	fail := func() (T, error) {
+		var zero T
+		return zero, typeMismatch(nil, expected.String(), v)
+	}
+
+	if isNil(&v) {
+		switch expected.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+			return res, nil
+		}
+		return res, nilValue(nil, expected.String())
+	}
+
+	switch p := any(&res).(type) {
+	case *string:
+		s, ok := v.(string)
+		if !ok {
+			return fail()
+		}
+		*p = s
+	case *bool:
+		b, ok := v.(bool)
+		if !ok {
+			return fail()
+		}
+		*p = b
+	case *int:
+		i, ok := intValue(v)
+		if !ok || i < math.MinInt || i > math.MaxInt {
+			return fail()
+		}
+		*p = int(i)
+	case *int8:
+		i, ok := intValue(v)
+		if !ok || i < math.MinInt8 || i > math.MaxInt8 {
+			return fail()
+		}
+		*p = int8(i)
+	case *int16:
+		i, ok := intValue(v)
+		if !ok || i < math.MinInt16 || i > math.MaxInt16 {
+			return fail()
+		}
+		*p = int16(i)
+	case *int32:
+		i, ok := intValue(v)
+		if !ok || i < math.MinInt32 || i > math.MaxInt32 {
+			return fail()
+		}
+		*p = int32(i)
+	case *int64:
+		i, ok := intValue(v)
+		if !ok {
+			return fail()
+		}
+		*p = i
+	case *uint:
+		u, ok := uintValue(v)
+		if !ok || u > math.MaxUint {
+			return fail()
+		}
+		*p = uint(u)
+	case *uint8:
+		u, ok := uintValue(v)
+		if !ok || u > math.MaxUint8 {
+			return fail()
+		}
+		*p = uint8(u)
+	case *uint16:
+		u, ok := uintValue(v)
+		if !ok || u > math.MaxUint16 {
+			return fail()
+		}
+		*p = uint16(u)
+	case *uint32:
+		u, ok := uintValue(v)
+		if !ok || u > math.MaxUint32 {
+			return fail()
+		}
+		*p = uint32(u)
+	case *uint64:
+		u, ok := uintValue(v)
+		if !ok {
+			return fail()
+		}
+		*p = u
+	case *float64:
+		f, ok := NumberValue(v)
+		if !ok {
+			return fail()
+		}
+		*p = f
+	case *float32:
+		f, ok := NumberValue(v)
+		if !ok {
+			return fail()
+		}
+		*p = float32(f)
+	case **big.Int:
+		b, ok := BigIntValue(v)
+		if !ok {
+			return fail()
+		}
+		*p = b
+	case *json.Number:
+		if _, ok := v.(string); ok {
+			return fail()
+		}
+		if err := fillStruct(v, p); err != nil {
+			return fail()
+		}
+	case *time.Time:
+		t, ok := TimeValue(v)
+		if !ok {
+			return fail()
+		}
+		*p = t
+	case *time.Duration:
+		d, ok := DurationValue(v)
+		if !ok {
+			return fail()
+		}
+		*p = d
+	case *[]byte:
+		b, ok := BytesValue(v)
+		if !ok {
+			return fail()
+		}
+		*p = b
+	case *IObject:
+		o, ok := ObjectValue(v)
+		if !ok {
+			return fail()
+		}
+		*p = o
+	case *IReadonlyObject:
+		o, ok := ReadonlyObjectValue(v)
+		if !ok {
+			return fail()
+		}
+		*p = o
+	case *IArray:
+		if a, ok := v.(IArray); ok {
+			*p = a
+			return res, nil
+		}
+		s, ok := SliceValue(v)
+		if !ok {
+			return fail()
+		}
+		*p = NewArrayFromSlice(s)
+	case *interface{}:
+		*p = v
+	default:
+		if x, ok := v.(T); ok {
+			return x, nil
+		}
+		if err := fillStruct(v, &res); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+	return res, nil
+}
+
+func GetAs[T any](o IReadonlyObject, key string) (T, error) {
+	v, ok := o.Get(key)
+	if !ok {
+		var zero T
+		return zero, notFound(key)
+	}
+	// arrays taken from objects should stay attached to them
+	var res T
+	if p, ok := any(&res).(*IArray); ok {
+		a, err := o.GetArray(key)
+		*p = a
+		return res, err
+	}
+	res, err := As[T](v)
+	return res, errorAtKey(err, key)
+}
+
+func OptAs[T any](o IReadonlyObject, key string, defaultvalue ...T) T {
+	v, err := GetAs[T](o, key)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		var zero T
+		return zero
+	}
+	return v
+}
+
+func GetIndexAs[T any](a IArray, index int) (T, error) {
+	v, ok := a.Get(index)
+	if !ok {
+		var zero T
+		return zero, notFound(index)
+	}
+	var res T
+	if p, ok := any(&res).(*IArray); ok {
+		x, err := a.GetArray(index)
+		*p = x
+		return res, err
+	}
+	res, err := As[T](v)
+	return res, errorAtKey(err, index)
+}
+
+// converts every element, fails on the first one which cannot be converted
+func ArrayAs[T any](a IArray) ([]T, error) {
+	res := make([]T, a.Length())
+	for i := range res {
+		v, err := GetIndexAs[T](a, i)
+		if err != nil {
+			return nil, FieldError{Path: MakePointer(fmt.Sprint(i)), Err: err}
+		}
+		res[i] = v
+	}
+	return res, nil
+}
+
+// IntValue, but floats and unsigned numbers out of int64 range fail instead of wrapping
+func intValue(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case float64:
+		if math.IsNaN(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+			return 0, false
+		}
+	case float32:
+		if math.IsNaN(float64(n)) || n < math.MinInt64 || n >= math.MaxInt64 {
+			return 0, false
+		}
+	case uint, uint64:
+		if u, _ := Uint64Value(n); u > math.MaxInt64 {
+			return 0, false
+		}
+	}
+	return IntValue(v)
+}
+
+// whole uint64 numbers as is, the rest is truncated like in IntValue
+func uintValue(v interface{}) (uint64, bool) {
+	if u, ok := Uint64Value(v); ok {
+		return u, true
+	}
+	i, ok := intValue(v)
+	return uint64(i), ok && i >= 0
+}
@@ -0,0 +1,71 @@
+package jsonlight
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetAs(t *testing.T) {
+	o := NewObjectOrDie(`{"i":42,"f":1.5,"s":"x","big":300,"list":[1,2],"obj":{"id":3},"null":null}`)
+
+	if i, err := GetAs[int](o, "i"); err != nil || i != 42 {
+		t.Errorf("unexpected %v %v", i, err)
+	}
+	if f, err := GetAs[float32](o, "f"); err != nil || f != 1.5 {
+		t.Errorf("unexpected %v %v", f, err)
+	}
+	if _, err := GetAs[int8](o, "big"); !errors.Is(err, TypeConvertError{}) {
+		t.Errorf("overflow should fail, got %v", err)
+	}
+	// same as IntValue
+	if i, err := GetAs[int](o, "f"); err != nil || i != 1 {
+		t.Errorf("fraction should be truncated for int: %v %v", i, err)
+	}
+	if u, err := GetAs[uint](o, "f"); err != nil || u != 1 {
+		t.Errorf("fraction should be truncated for uint: %v %v", u, err)
+	}
+	if _, err := As[int64](1e300); !errors.Is(err, TypeConvertError{}) {
+		t.Errorf("overflow should fail, got %v", err)
+	}
+	if i, err := As[int64](1e3); err != nil || i != 1000 {
+		t.Errorf("whole float: %v %v", i, err)
+	}
+	_, err := GetAs[bool](o, "s")
+	if err == nil || err.Error() != "Type convertion error at /s: expected bool, got string (string)" {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := GetAs[string](o, "missing"); !errors.Is(err, NotFoundError{}) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := GetAs[string](o, "null"); !errors.Is(err, NilConvertError{}) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	a, _ := GetAs[IArray](o, "list")
+	a.Append(3)
+	if o.OptArray("list").Length() != 3 {
+		t.Error("array should stay attached to object")
+	}
+
+	type item struct {
+		ID int `json:"id"`
+	}
+	if it, err := GetAs[item](o, "obj"); err != nil || it.ID != 3 {
+		t.Errorf("unexpected %v %v", it, err)
+	}
+	if OptAs(o, "s", 7) != 7 {
+		t.Error("default value expected")
+	}
+}
+
+func TestArrayAs(t *testing.T) {
+	s, err := ArrayAs[string](NewArrayOrDie(`["a","b"]`))
+	if err != nil || len(s) != 2 || s[1] != "b" {
+		t.Errorf("unexpected %v %v", s, err)
+	}
+	_, err = ArrayAs[int](NewArrayOrDie(`[1,"2"]`))
+	var fe FieldError
+	if !errors.As(err, &fe) || fe.Path != "/1" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package jsonlight
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ${...} references in string values:
+//   - ${db.host} or ${servers.0.addr} is value of other key, ${/a/b} is JSON pointer
+//   - ${env:HOME}, ${file:secret.txt} or ${scheme:name} of any other Resolver
+//   - ${x:-fallback} uses fallback if x is missing or null, fallback may contain references too
+//   - $${ is literal ${
+// string which is exactly one reference gets referenced value with its type,
+// e.g. "${db.port}" becomes number, other references are embedded as text
+
+// resolves ${scheme:name} references, found is false if there is no such value
+type Resolver struct {
+	Scheme  string
+	Resolve func(name string) (value interface{}, found bool, err error)
+}
+
+func EnvResolver() Resolver {
+	return Resolver{Scheme: "env", Resolve: func(name string) (interface{}, bool, error) {
+		v, ok := os.LookupEnv(name)
+		return v, ok, nil
+	}}
+}
+
+// contents of file relative to dir, trailing newline is dropped
+func FileResolver(dir string) Resolver {
+	return Resolver{Scheme: "file", Resolve: func(name string) (interface{}, bool, error) {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		b, err := os.ReadFile(name)
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		return strings.TrimRight(string(b), "\r\n"), true, nil
+	}}
+}
+
+type InterpolationError struct {
+	// string which has the reference
+	Pointer string
+	Ref     string
+	Err     error
+}
+
+func (e InterpolationError) Error() string {
+	return fmt.Sprintf("cannot interpolate ${%s} at %s: %v", e.Ref, e.Pointer, e.Err)
+}
+
+func (e InterpolationError) Unwrap() error { return e.Err }
+
+type ReferenceCycleError struct {
+	Chain []string
+}
+
+func (e ReferenceCycleError) Error() string {
+	return "reference cycle: " + strings.Join(e.Chain, " -> ")
+}
+
+// expands references in all string values of o in place.
+// env and file (relative to working directory) resolvers are always available,
+// resolvers with the same scheme replace them.
+// o can be partially changed if error is returned
+func Interpolate(o IObject, resolvers ...Resolver) error {
+	if o == nil {
+		return errors.New("Interpolate called with nil param")
+	}
+	this := &interpolator{
+		root:      o,
+		resolvers: map[string]Resolver{},
+		done:      map[string]bool{},
+		active:    map[string]bool{},
+	}
+	for _, r := range append([]Resolver{EnvResolver(), FileResolver("")}, resolvers...) {
+		this.resolvers[r.Scheme] = r
+	}
+	for _, k := range stableKeys(o) {
+		if _, err := this.value(MakePointer(k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type interpolator struct {
+	root      IObject
+	resolvers map[string]Resolver
+	// pointers which are expanded already
+	done   map[string]bool
+	active map[string]bool
+	stack  []string
+}
+
+// expanded value at pointer, it is written back to root
+func (this *interpolator) value(pointer string) (interface{}, error) {
+	v, ok := this.root.GetPointer(pointer)
+	if !ok {
+		return nil, notFound(pointer)
+	}
+	if this.done[pointer] {
+		return v, nil
+	}
+	if this.active[pointer] {
+		i := 0
+		for this.stack[i] != pointer {
+			i++
+		}
+		return nil, ReferenceCycleError{Chain: append(append([]string(nil), this.stack[i:]...), pointer)}
+	}
+	this.active[pointer] = true
+	this.stack = append(this.stack, pointer)
+	defer func() {
+		delete(this.active, pointer)
+		this.stack = this.stack[:len(this.stack)-1]
+	}()
+
+	if s, ok := v.(string); ok {
+		res, err := this.expand(pointer, s)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := this.root.PutPointer(pointer, res); err != nil {
+			return nil, err
+		}
+		v = res
+	} else if o, ok := ReadonlyObjectValue(v); ok {
+		for _, k := range stableKeys(o) {
+			if _, err := this.value(pointer + "/" + EscapePointerToken(k)); err != nil {
+				return nil, err
+			}
+		}
+	} else if s, ok := SliceValue(v); ok {
+		for i := range s {
+			if _, err := this.value(fmt.Sprintf("%s/%d", pointer, i)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	this.done[pointer] = true
+	return v, nil
+}
+
+// s with references replaced, typed value if s is single reference
+func (this *interpolator) expand(pointer string, s string) (interface{}, error) {
+	var buf strings.Builder
+	for i := 0; i < len(s); {
+		if strings.HasPrefix(s[i:], "$${") {
+			buf.WriteString("${")
+			i += 3
+			continue
+		}
+		if !strings.HasPrefix(s[i:], "${") {
+			buf.WriteByte(s[i])
+			i++
+			continue
+		}
+		end := closingBrace(s, i+2)
+		if end < 0 {
+			return nil, InterpolationError{Pointer: pointer, Ref: s[i+2:], Err: errors.New("missing }")}
+		}
+		ref := s[i+2 : end]
+		v, err := this.reference(pointer, ref)
+		if err != nil {
+			var ie InterpolationError
+			var ce ReferenceCycleError
+			if errors.As(err, &ie) || errors.As(err, &ce) {
+				return nil, err
+			}
+			return nil, InterpolationError{Pointer: pointer, Ref: ref, Err: err}
+		}
+		if i == 0 && end == len(s)-1 {
+			return DeepCopy(v), nil
+		}
+		if str, ok := v.(string); ok {
+			buf.WriteString(str)
+		} else {
+			buf.WriteString(jsonString(v))
+		}
+		i = end + 1
+	}
+	return buf.String(), nil
+}
+
+// index of } which closes reference started before from, or -1
+func closingBrace(s string, from int) int {
+	depth := 0
+	for i := from; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "${"):
+			depth++
+			i++
+		case s[i] == '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+func (this *interpolator) reference(pointer string, ref string) (interface{}, error) {
+	name, fallback, hasFallback := ref, "", false
+	if i := strings.Index(ref, ":-"); i >= 0 && i < closingOrEnd(ref) {
+		name, fallback, hasFallback = ref[:i], ref[i+2:], true
+	}
+
+	v, found, err := this.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if !found || isNil(&v) {
+		if hasFallback {
+			return this.expand(pointer, fallback)
+		}
+		if !found {
+			return nil, notFound(name)
+		}
+	}
+	return v, nil
+}
+
+// fallback separator should not be taken from nested reference
+func closingOrEnd(ref string) int {
+	if i := strings.Index(ref, "${"); i >= 0 {
+		return i
+	}
+	return len(ref)
+}
+
+func (this *interpolator) lookup(name string) (interface{}, bool, error) {
+	if i := strings.Index(name, ":"); i > 0 {
+		if r, ok := this.resolvers[name[:i]]; ok {
+			return r.Resolve(name[i+1:])
+		}
+	}
+	pointer := name
+	if !strings.HasPrefix(name, "/") {
+		pointer = MakePointer(strings.Split(name, ".")...)
+	}
+	if !this.root.HasPointer(pointer) {
+		return nil, false, nil
+	}
+	v, err := this.value(pointer)
+	return v, err == nil, err
+}
@@ -0,0 +1,241 @@
+package jsonlight
+
+import (
+	"iter"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// range-over-func iterators.
+// All yields raw values the same way Get does, typed iterators skip values of other types.
+// objects are yielded as IObject and arrays as live IArray views.
+// Walk yields every nested value with its JSON pointer, parents before children;
+// keys of plain objects are walked in sorted order, ordered objects keep their order
+
+func (this *JSONObject) All() iter.Seq2[string, interface{}] {
+	return func(yield func(string, interface{}) bool) {
+		for k, v := range this.ToMap() {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+func (this *JSONObject) SortedAll() iter.Seq2[string, interface{}] {
+	return sortedAll(this)
+}
+
+func (this *JSONObject) Objects() iter.Seq2[string, IObject] {
+	return objectsOf(this.All())
+}
+
+func (this *JSONObject) Arrays() iter.Seq2[string, IArray] {
+	return arraysOf(this.All(), this.GetArray)
+}
+
+func (this *JSONObject) Strings() iter.Seq2[string, string] {
+	return stringsOf(this.All())
+}
+
+func (this *JSONObject) Walk() iter.Seq2[string, interface{}] {
+	return walk(this)
+}
+
+// keys in document order
+func (this *OrderedObject) All() iter.Seq2[string, interface{}] {
+	return func(yield func(string, interface{}) bool) {
+		for _, k := range this.Keys() {
+			if v, ok := this.JSONObject[k]; ok && !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+func (this *OrderedObject) SortedAll() iter.Seq2[string, interface{}] {
+	return sortedAll(this)
+}
+
+func (this *OrderedObject) Objects() iter.Seq2[string, IObject] {
+	return objectsOf(this.All())
+}
+
+func (this *OrderedObject) Arrays() iter.Seq2[string, IArray] {
+	return arraysOf(this.All(), this.GetArray)
+}
+
+func (this *OrderedObject) Strings() iter.Seq2[string, string] {
+	return stringsOf(this.All())
+}
+
+func (this *OrderedObject) Walk() iter.Seq2[string, interface{}] {
+	return walk(this)
+}
+
+// wrapper iterates over snapshot taken under the lock, so loop body may use the wrapper.
+// values are deep copies, changing them does not change the wrapped object
+
+func (this *SynchronizedObjectWrapper) All() iter.Seq2[string, interface{}] {
+	return lockedSeq(&this.Mutex, func() iter.Seq2[string, interface{}] { return this.O.All() }, DeepCopy)
+}
+
+func (this *SynchronizedObjectWrapper) SortedAll() iter.Seq2[string, interface{}] {
+	return lockedSeq(&this.Mutex, func() iter.Seq2[string, interface{}] { return this.O.SortedAll() }, DeepCopy)
+}
+
+func (this *SynchronizedObjectWrapper) Objects() iter.Seq2[string, IObject] {
+	return lockedSeq(&this.Mutex, func() iter.Seq2[string, IObject] { return this.O.Objects() }, copyObject)
+}
+
+func (this *SynchronizedObjectWrapper) Arrays() iter.Seq2[string, IArray] {
+	return lockedSeq(&this.Mutex, func() iter.Seq2[string, IArray] { return this.O.Arrays() }, copyArray)
+}
+
+func (this *SynchronizedObjectWrapper) Strings() iter.Seq2[string, string] {
+	return lockedSeq(&this.Mutex, func() iter.Seq2[string, string] { return this.O.Strings() }, func(s string) string { return s })
+}
+
+func (this *SynchronizedObjectWrapper) Walk() iter.Seq2[string, interface{}] {
+	return lockedSeq(&this.Mutex, func() iter.Seq2[string, interface{}] { return this.O.Walk() }, DeepCopy)
+}
+
+func (this *JSONArray) All() iter.Seq2[int, interface{}] {
+	return func(yield func(int, interface{}) bool) {
+		for i, v := range this.resolve() {
+			if !yield(i, v) {
+				return
+			}
+		}
+	}
+}
+
+func (this *JSONArray) Objects() iter.Seq2[int, IObject] {
+	return objectsOf(this.All())
+}
+
+func (this *JSONArray) Arrays() iter.Seq2[int, IArray] {
+	return arraysOf(this.All(), this.GetArray)
+}
+
+func (this *JSONArray) Strings() iter.Seq2[int, string] {
+	return stringsOf(this.All())
+}
+
+func (this *JSONArray) Walk() iter.Seq2[string, interface{}] {
+	return walk(this)
+}
+
+//------------------
+
+func sortedAll(o IReadonlyObject) iter.Seq2[string, interface{}] {
+	return func(yield func(string, interface{}) bool) {
+		keys := o.Keys()
+		sort.Strings(keys)
+		for _, k := range keys {
+			if v, ok := o.Get(k); ok && !yield(k, v) {
+				return
+			}
+		}
+	}
+}
+
+func objectsOf[K any](seq iter.Seq2[K, interface{}]) iter.Seq2[K, IObject] {
+	return func(yield func(K, IObject) bool) {
+		for k, v := range seq {
+			if o, ok := ObjectValue(v); ok && !yield(k, o) {
+				return
+			}
+		}
+	}
+}
+
+func arraysOf[K any](seq iter.Seq2[K, interface{}], get func(K) (IArray, error)) iter.Seq2[K, IArray] {
+	return func(yield func(K, IArray) bool) {
+		for k, v := range seq {
+			if _, ok := v.([]interface{}); !ok {
+				continue
+			}
+			if a, err := get(k); err == nil && !yield(k, a) {
+				return
+			}
+		}
+	}
+}
+
+func stringsOf[K any](seq iter.Seq2[K, interface{}]) iter.Seq2[K, string] {
+	return func(yield func(K, string) bool) {
+		for k, v := range seq {
+			if s, ok := v.(string); ok && !yield(k, s) {
+				return
+			}
+		}
+	}
+}
+
+func walk(root interface{}) iter.Seq2[string, interface{}] {
+	return func(yield func(string, interface{}) bool) {
+		walkValue("", root, yield)
+	}
+}
+
+// false if iteration was stopped
+func walkValue(path string, v interface{}, yield func(string, interface{}) bool) bool {
+	if o, ok := ReadonlyObjectValue(v); ok {
+		for _, k := range stableKeys(o) {
+			x, _ := o.Get(k)
+			p := path + "/" + EscapePointerToken(k)
+			if !yield(p, x) || !walkValue(p, x, yield) {
+				return false
+			}
+		}
+	} else if s, ok := SliceValue(v); ok {
+		for i, x := range s {
+			p := path + "/" + strconv.Itoa(i)
+			if !yield(p, x) || !walkValue(p, x, yield) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// collects pairs of seq() while holding the lock, values are copied,
+// so nothing shared with the locked object is used after unlock
+func lockedSeq[K, V any](mu *sync.Mutex, seq func() iter.Seq2[K, V], copyValue func(V) V) iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		keys, values := collectLocked(mu, seq, copyValue)
+		for i := range keys {
+			if !yield(keys[i], values[i]) {
+				return
+			}
+		}
+	}
+}
+
+func collectLocked[K, V any](mu *sync.Mutex, seq func() iter.Seq2[K, V], copyValue func(V) V) ([]K, []V) {
+	mu.Lock()
+	defer mu.Unlock()
+	var keys []K
+	var values []V
+	for k, v := range seq() {
+		keys = append(keys, k)
+		values = append(values, copyValue(v))
+	}
+	return keys, values
+}
+
+func copyObject(o IObject) IObject {
+	if c, ok := ObjectValue(DeepCopy(o)); ok {
+		return c
+	}
+	return o
+}
+
+func copyArray(a IArray) IArray {
+	if s, ok := SliceValue(DeepCopy(a)); ok {
+		return NewArrayFromSlice(s)
+	}
+	return a
+}
@@ -0,0 +1,173 @@
+package jsonlight
+
+import (
+	"iter"
+	"strings"
+	"testing"
+)
+
+func TestObjectIterators(t *testing.T) {
+	doc := `{"b":"x","a":{"n":1},"c":[{"k":"v"},"s"],"d":2}`
+	o := NewObjectOrDie(doc)
+
+	count := 0
+	for k, v := range o.All() {
+		if got, _ := o.Get(k); !ValuesEqual(got, v) {
+			t.Errorf("All: %s = %v", k, v)
+		}
+		count++
+	}
+	if count != 4 {
+		t.Errorf("All yielded %d pairs", count)
+	}
+
+	keys := []string{}
+	for k := range o.SortedAll() {
+		keys = append(keys, k)
+		if k == "b" {
+			break
+		}
+	}
+	if strings.Join(keys, ",") != "a,b" {
+		t.Errorf("SortedAll: %v", keys)
+	}
+
+	for k, obj := range o.Objects() {
+		if k != "a" || obj.OptInt("n") != 1 {
+			t.Errorf("Objects: %s %v", k, obj)
+		}
+		obj.Put("n", 5)
+	}
+	if o.OptObject("a").OptInt("n") != 5 {
+		t.Error("Objects should yield live objects")
+	}
+	for _, a := range o.Arrays() {
+		a.Append(true)
+	}
+	if o.OptArray("c").Length() != 3 {
+		t.Error("Arrays should yield live views")
+	}
+	for k, s := range o.Strings() {
+		if k != "b" || s != "x" {
+			t.Errorf("Strings: %s %s", k, s)
+		}
+	}
+
+	ordered, _ := NewOrderedObjectFromString(doc)
+	keys = keys[:0]
+	for k := range ordered.All() {
+		keys = append(keys, k)
+	}
+	if strings.Join(keys, ",") != "b,a,c,d" {
+		t.Errorf("ordered All: %v", keys)
+	}
+
+	synced := GetSynchronizedWrapper(ordered)
+	for k := range synced.All() {
+		// lock is not held by the loop
+		synced.Put(k+"2", 1)
+	}
+	if synced.Length() != 8 {
+		t.Errorf("synced: %s", synced.ToString())
+	}
+
+	// yielded values are copies, so they can be used without the lock
+	for _, x := range synced.Objects() {
+		x.Put("n", 2)
+	}
+	for _, x := range synced.Arrays() {
+		x.Append(3)
+	}
+	if s := synced.OptObject("a").ToString(); s != `{"n":1}` {
+		t.Errorf("object copy changed the wrapped one: %s", s)
+	}
+	if n := synced.OptArray("c").Length(); n != 2 {
+		t.Errorf("array copy changed the wrapped one: %d elements", n)
+	}
+}
+
+type panickyObject struct {
+	*JSONObject
+}
+
+func (this panickyObject) All() iter.Seq2[string, interface{}] {
+	panic("boom")
+}
+
+func TestSynchronizedIteratorPanic(t *testing.T) {
+	synced := GetSynchronizedWrapper(panickyObject{NewObjectOrDie(`{"a":1}`).(*JSONObject)}).(*SynchronizedObjectWrapper)
+	func() {
+		defer func() { recover() }()
+		for range synced.All() {
+		}
+	}()
+	if !synced.Mutex.TryLock() {
+		t.Fatal("lock is still held after panic")
+	}
+	synced.Mutex.Unlock()
+}
+
+func TestWalk(t *testing.T) {
+	o, _ := NewOrderedObjectFromString(`{"b":{"x/y":[1,{"z":null}]},"a":true}`)
+	paths := []string{}
+	for p := range o.Walk() {
+		paths = append(paths, p)
+	}
+	want := "/b,/b/x~1y,/b/x~1y/0,/b/x~1y/1,/b/x~1y/1/z,/a"
+	if s := strings.Join(paths, ","); s != want {
+		t.Errorf("Walk: %s", s)
+	}
+
+	for p, v := range o.Walk() {
+		if got, _ := o.GetPointer(p); !ValuesEqual(got, v) {
+			t.Errorf("Walk value at %s: %v", p, v)
+		}
+	}
+
+	a := NewArrayOrDie(`[{"k":1},"s",[2]]`)
+	paths = paths[:0]
+	for p := range a.Walk() {
+		paths = append(paths, p)
+		if p == "/1" {
+			break
+		}
+	}
+	if s := strings.Join(paths, ","); s != "/0,/0/k,/1" {
+		t.Errorf("array Walk: %s", s)
+	}
+}
+
+func TestArrayIterators(t *testing.T) {
+	a := NewArrayOrDie(`[{"k":1},"s",[2],null,"t"]`)
+	n := 0
+	for i, v := range a.All() {
+		if got, _ := a.Get(i); i != n || !ValuesEqual(v, got) {
+			t.Errorf("All: %d %v", i, v)
+		}
+		n++
+	}
+	if n != 5 {
+		t.Errorf("All yielded %d elements", n)
+	}
+	for i, o := range a.Objects() {
+		if i != 0 || o.OptInt("k") != 1 {
+			t.Errorf("Objects: %d %v", i, o)
+		}
+	}
+	for i, x := range a.Arrays() {
+		if i != 2 {
+			t.Errorf("Arrays: %d", i)
+		}
+		x.Append(3)
+	}
+	if a.OptArray(2).Length() != 2 {
+		t.Error("Arrays should yield live views")
+	}
+	s := []string{}
+	for _, x := range a.Strings() {
+		s = append(s, x)
+	}
+	if strings.Join(s, ",") != "s,t" {
+		t.Errorf("Strings: %v", s)
+	}
+}
@@ -0,0 +1,1055 @@
+package jsonlight
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// JSONPath (RFC 9535) queries: $.store.book[?@.price < 10].title, $..author, $[0:10:2] etc.
+// supported functions: length(), count(), match(), search(), value()
+
+type JSONPath struct {
+	expr  string
+	query *jpQuery
+}
+
+func CompileJSONPath(expr string) (*JSONPath, error) {
+	p := &jpParser{s: expr}
+	if p.peek() != '$' {
+		return nil, p.errorf("query should start with $")
+	}
+	q, err := p.parseQuery()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.s) {
+		return nil, p.errorf("unexpected character %q", p.s[p.pos])
+	}
+	return &JSONPath{expr: expr, query: q}, nil
+}
+
+func MustCompileJSONPath(expr string) *JSONPath {
+	p, err := CompileJSONPath(expr)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (this *JSONPath) String() string {
+	return this.expr
+}
+
+// root can be IObject, IArray or raw value (map, slice...)
+func (this *JSONPath) Query(root interface{}) IArray {
+	nodes := this.query.nodes(jpNode{value: root}, jpNode{value: root})
+	res := make([]interface{}, len(nodes))
+	for i, n := range nodes {
+		res[i] = n.value
+	}
+	return NewArrayFromSlice(res)
+}
+
+// normalized paths of matched values, e.g. $['store']['book'][0]
+func (this *JSONPath) QueryPaths(root interface{}) []string {
+	nodes := this.query.nodes(jpNode{value: root}, jpNode{value: root})
+	res := make([]string, len(nodes))
+	for i, n := range nodes {
+		res[i] = n.normalizedPath()
+	}
+	return res
+}
+
+//-------------------------------------
+
+func (this *JSONObject) Query(expr string) (IArray, error) {
+	return queryJSONPath(this, expr)
+}
+func (this *JSONObject) QueryPaths(expr string) ([]string, error) {
+	return queryJSONPathPaths(this, expr)
+}
+
+// overridden so that members are visited in key order
+func (this *OrderedObject) Query(expr string) (IArray, error) {
+	return queryJSONPath(this, expr)
+}
+func (this *OrderedObject) QueryPaths(expr string) ([]string, error) {
+	return queryJSONPathPaths(this, expr)
+}
+
+func (this *JSONArray) Query(expr string) (IArray, error) {
+	return queryJSONPath(this, expr)
+}
+func (this *JSONArray) QueryPaths(expr string) ([]string, error) {
+	return queryJSONPathPaths(this, expr)
+}
+
+func queryJSONPath(root interface{}, expr string) (IArray, error) {
+	p, err := CompileJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.Query(root), nil
+}
+
+func queryJSONPathPaths(root interface{}, expr string) ([]string, error) {
+	p, err := CompileJSONPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	return p.QueryPaths(root), nil
+}
+
+//-------------------------------------
+// evaluation
+
+type jpNode struct {
+	// string keys and int indexes from the root
+	path  []interface{}
+	value interface{}
+}
+
+func (this jpNode) child(key interface{}, value interface{}) jpNode {
+	path := make([]interface{}, len(this.path)+1)
+	copy(path, this.path)
+	path[len(this.path)] = key
+	return jpNode{path: path, value: value}
+}
+
+// calls fn for each member or element in document order
+func (this jpNode) children(fn func(jpNode)) {
+	if o, ok := ObjectValue(this.value); ok {
+		for _, k := range stableKeys(o) {
+			v, _ := o.Get(k)
+			fn(this.child(k, v))
+		}
+	} else if s, ok := SliceValue(this.value); ok {
+		for i, v := range s {
+			fn(this.child(i, v))
+		}
+	}
+}
+
+func (this jpNode) descendants(fn func(jpNode)) {
+	fn(this)
+	this.children(func(c jpNode) {
+		c.descendants(fn)
+	})
+}
+
+func (this jpNode) normalizedPath() string {
+	var b strings.Builder
+	b.WriteByte('$')
+	for _, p := range this.path {
+		switch k := p.(type) {
+		case int:
+			b.WriteByte('[')
+			b.WriteString(strconv.Itoa(k))
+			b.WriteByte(']')
+		case string:
+			b.WriteString("['")
+			for _, r := range k {
+				switch r {
+				case '\'':
+					b.WriteString(`\'`)
+				case '\\':
+					b.WriteString(`\\`)
+				case '\b':
+					b.WriteString(`\b`)
+				case '\f':
+					b.WriteString(`\f`)
+				case '\n':
+					b.WriteString(`\n`)
+				case '\r':
+					b.WriteString(`\r`)
+				case '\t':
+					b.WriteString(`\t`)
+				default:
+					if r < 0x20 {
+						fmt.Fprintf(&b, `\u%04x`, r)
+					} else {
+						b.WriteRune(r)
+					}
+				}
+			}
+			b.WriteString("']")
+		}
+	}
+	return b.String()
+}
+
+type jpQuery struct {
+	relative bool
+	segments []jpSegment
+}
+
+func (this *jpQuery) nodes(root, cur jpNode) []jpNode {
+	nodes := []jpNode{root}
+	if this.relative {
+		nodes = []jpNode{cur}
+	}
+	for _, seg := range this.segments {
+		next := make([]jpNode, 0)
+		for _, n := range nodes {
+			if seg.descendant {
+				n.descendants(func(d jpNode) {
+					next = seg.apply(d, root, next)
+				})
+			} else {
+				next = seg.apply(n, root, next)
+			}
+		}
+		nodes = next
+	}
+	return nodes
+}
+
+// singular query consists of name and index selectors only
+func (this *jpQuery) singular() bool {
+	for _, seg := range this.segments {
+		if seg.descendant || len(seg.selectors) != 1 {
+			return false
+		}
+		if k := seg.selectors[0].kind; k != jpName && k != jpIndex {
+			return false
+		}
+	}
+	return true
+}
+
+type jpSegment struct {
+	descendant bool
+	selectors  []jpSelector
+}
+
+func (this jpSegment) apply(n jpNode, root jpNode, out []jpNode) []jpNode {
+	for _, sel := range this.selectors {
+		out = sel.apply(n, root, out)
+	}
+	return out
+}
+
+const (
+	jpName = iota
+	jpWildcard
+	jpIndex
+	jpSlice
+	jpFilter
+)
+
+type jpSelector struct {
+	kind   int
+	name   string
+	index  int
+	start  *int
+	end    *int
+	step   *int
+	filter jpLogical
+}
+
+func (this jpSelector) apply(n jpNode, root jpNode, out []jpNode) []jpNode {
+	switch this.kind {
+	case jpName:
+		if o, ok := ObjectValue(n.value); ok {
+			if v, ok := o.Get(this.name); ok {
+				out = append(out, n.child(this.name, v))
+			}
+		}
+	case jpWildcard:
+		n.children(func(c jpNode) {
+			out = append(out, c)
+		})
+	case jpIndex:
+		if s, ok := SliceValue(n.value); ok {
+			i := this.index
+			if i < 0 {
+				i += len(s)
+			}
+			if i >= 0 && i < len(s) {
+				out = append(out, n.child(i, s[i]))
+			}
+		}
+	case jpSlice:
+		if s, ok := SliceValue(n.value); ok {
+			for _, i := range this.sliceIndexes(len(s)) {
+				out = append(out, n.child(i, s[i]))
+			}
+		}
+	case jpFilter:
+		n.children(func(c jpNode) {
+			if this.filter.test(root, c) {
+				out = append(out, c)
+			}
+		})
+	}
+	return out
+}
+
+func (this jpSelector) sliceIndexes(length int) []int {
+	step := 1
+	if this.step != nil {
+		step = *this.step
+	}
+	if step == 0 {
+		return nil
+	}
+	normalize := func(i int) int {
+		if i >= 0 {
+			return i
+		}
+		return length + i
+	}
+	clamp := func(i, lo, hi int) int {
+		if i < lo {
+			return lo
+		}
+		if i > hi {
+			return hi
+		}
+		return i
+	}
+
+	res := make([]int, 0)
+	if step > 0 {
+		start, end := 0, length
+		if this.start != nil {
+			start = normalize(*this.start)
+		}
+		if this.end != nil {
+			end = normalize(*this.end)
+		}
+		for i := clamp(start, 0, length); i < clamp(end, 0, length); i += step {
+			res = append(res, i)
+		}
+	} else {
+		start, end := length-1, -length-1
+		if this.start != nil {
+			start = normalize(*this.start)
+		}
+		if this.end != nil {
+			end = normalize(*this.end)
+		}
+		for i := clamp(start, -1, length-1); i > clamp(end, -1, length-1); i += step {
+			res = append(res, i)
+		}
+	}
+	return res
+}
+
+//-------------------------------------
+// filter expressions
+
+type jpLogical interface {
+	test(root, cur jpNode) bool
+}
+
+// literal, singular query or function returning value
+type jpComparable interface {
+	value(root, cur jpNode) (interface{}, bool)
+}
+
+type jpOr []jpLogical
+type jpAnd []jpLogical
+type jpNot struct{ expr jpLogical }
+type jpExists struct{ query *jpQuery }
+type jpFunctionTest struct{ f *jpFunction }
+type jpComparison struct {
+	op          string
+	left, right jpComparable
+}
+type jpLiteral struct{ v interface{} }
+type jpSingularQuery struct{ query *jpQuery }
+
+func (this jpOr) test(root, cur jpNode) bool {
+	for _, e := range this {
+		if e.test(root, cur) {
+			return true
+		}
+	}
+	return false
+}
+
+func (this jpAnd) test(root, cur jpNode) bool {
+	for _, e := range this {
+		if !e.test(root, cur) {
+			return false
+		}
+	}
+	return true
+}
+
+func (this jpNot) test(root, cur jpNode) bool {
+	return !this.expr.test(root, cur)
+}
+
+func (this jpExists) test(root, cur jpNode) bool {
+	return len(this.query.nodes(root, cur)) > 0
+}
+
+func (this jpFunctionTest) test(root, cur jpNode) bool {
+	v, ok := this.f.value(root, cur)
+	b, isBool := v.(bool)
+	return ok && isBool && b
+}
+
+func (this jpLiteral) value(root, cur jpNode) (interface{}, bool) {
+	return this.v, true
+}
+
+func (this jpSingularQuery) value(root, cur jpNode) (interface{}, bool) {
+	nodes := this.query.nodes(root, cur)
+	if len(nodes) != 1 {
+		return nil, false
+	}
+	return nodes[0].value, true
+}
+
+func (this jpComparison) test(root, cur jpNode) bool {
+	a, aok := this.left.value(root, cur)
+	b, bok := this.right.value(root, cur)
+	switch this.op {
+	case "==":
+		return jpEqual(a, aok, b, bok)
+	case "!=":
+		return !jpEqual(a, aok, b, bok)
+	case "<":
+		return jpLess(a, aok, b, bok)
+	case "<=":
+		return jpLess(a, aok, b, bok) || jpEqual(a, aok, b, bok)
+	case ">":
+		return jpLess(b, bok, a, aok)
+	case ">=":
+		return jpLess(b, bok, a, aok) || jpEqual(a, aok, b, bok)
+	}
+	return false
+}
+
+// missing values (Nothing in rfc terms) are equal only to each other
+func jpEqual(a interface{}, aok bool, b interface{}, bok bool) bool {
+	if !aok || !bok {
+		return !aok && !bok
+	}
+	return ValuesEqual(a, b)
+}
+
+func jpLess(a interface{}, aok bool, b interface{}, bok bool) bool {
+	if !aok || !bok {
+		return false
+	}
+	if af, ok := NumberValue(a); ok {
+		bf, ok := NumberValue(b)
+		return ok && af < bf
+	}
+	if as, ok := a.(string); ok {
+		bs, ok := b.(string)
+		return ok && as < bs
+	}
+	return false
+}
+
+type jpFunction struct {
+	name string
+	// *jpQuery for nodes arguments, jpComparable otherwise
+	args []interface{}
+	re   *regexp.Regexp
+}
+
+var jpFunctions = map[string]struct {
+	args    int
+	logical bool
+	nodes   bool // whether argument is nodelist
+}{
+	"length": {1, false, false},
+	"count":  {1, false, true},
+	"value":  {1, false, true},
+	"match":  {2, true, false},
+	"search": {2, true, false},
+}
+
+func (this *jpFunction) logical() bool {
+	return jpFunctions[this.name].logical
+}
+
+func (this *jpFunction) value(root, cur jpNode) (interface{}, bool) {
+	switch this.name {
+	case "length":
+		v, ok := this.args[0].(jpComparable).value(root, cur)
+		if !ok {
+			return nil, false
+		}
+		if s, ok := v.(string); ok {
+			return float64(utf8.RuneCountInString(s)), true
+		}
+		if s, ok := SliceValue(v); ok {
+			return float64(len(s)), true
+		}
+		if o, ok := ObjectValue(v); ok {
+			return float64(o.Length()), true
+		}
+		return nil, false
+	case "count":
+		return float64(len(this.args[0].(*jpQuery).nodes(root, cur))), true
+	case "value":
+		nodes := this.args[0].(*jpQuery).nodes(root, cur)
+		if len(nodes) != 1 {
+			return nil, false
+		}
+		return nodes[0].value, true
+	case "match", "search":
+		v, ok := this.args[0].(jpComparable).value(root, cur)
+		s, isString := v.(string)
+		if !ok || !isString {
+			return false, true
+		}
+		re := this.re
+		if re == nil {
+			p, ok := this.args[1].(jpComparable).value(root, cur)
+			ps, isString := p.(string)
+			if !ok || !isString {
+				return false, true
+			}
+			var err error
+			if re, err = compileJPRegexp(ps, this.name == "match"); err != nil {
+				return false, true
+			}
+		}
+		return re.MatchString(s), true
+	}
+	return nil, false
+}
+
+func compileJPRegexp(pattern string, full bool) (*regexp.Regexp, error) {
+	if full {
+		pattern = `\A(?:` + pattern + `)\z`
+	}
+	return regexp.Compile(pattern)
+}
+
+//-------------------------------------
+// parsing
+
+type jpParser struct {
+	s   string
+	pos int
+}
+
+func (this *jpParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("JSONPath: %s at position %d", fmt.Sprintf(format, args...), this.pos)
+}
+
+func (this *jpParser) peek() byte {
+	if this.pos < len(this.s) {
+		return this.s[this.pos]
+	}
+	return 0
+}
+
+func (this *jpParser) skipSpaces() {
+	for this.pos < len(this.s) {
+		switch this.s[this.pos] {
+		case ' ', '\t', '\n', '\r':
+			this.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (this *jpParser) consume(prefix string) bool {
+	if strings.HasPrefix(this.s[this.pos:], prefix) {
+		this.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+// $ or @ followed by segments
+func (this *jpParser) parseQuery() (*jpQuery, error) {
+	q := &jpQuery{relative: this.peek() == '@'}
+	this.pos++
+	for {
+		save := this.pos
+		this.skipSpaces()
+		c := this.peek()
+		if c != '.' && c != '[' {
+			this.pos = save
+			return q, nil
+		}
+		seg, err := this.parseSegment()
+		if err != nil {
+			return nil, err
+		}
+		q.segments = append(q.segments, seg)
+	}
+}
+
+func (this *jpParser) parseSegment() (jpSegment, error) {
+	seg := jpSegment{}
+	if this.consume("..") {
+		seg.descendant = true
+		if this.peek() == '[' {
+			return this.parseBracketed(seg)
+		}
+	} else if this.consume(".") {
+		if this.peek() == '[' {
+			return seg, this.errorf("unexpected [ after .")
+		}
+	} else {
+		return this.parseBracketed(seg)
+	}
+
+	if this.consume("*") {
+		seg.selectors = []jpSelector{{kind: jpWildcard}}
+		return seg, nil
+	}
+	name := this.parseMemberName()
+	if name == "" {
+		return seg, this.errorf("member name expected")
+	}
+	seg.selectors = []jpSelector{{kind: jpName, name: name}}
+	return seg, nil
+}
+
+func isJPNameChar(c byte, first bool) bool {
+	return c == '_' || c >= 0x80 || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+func (this *jpParser) parseMemberName() string {
+	start := this.pos
+	for this.pos < len(this.s) && isJPNameChar(this.s[this.pos], this.pos == start) {
+		this.pos++
+	}
+	return this.s[start:this.pos]
+}
+
+func (this *jpParser) parseBracketed(seg jpSegment) (jpSegment, error) {
+	if !this.consume("[") {
+		return seg, this.errorf("[ expected")
+	}
+	for {
+		this.skipSpaces()
+		sel, err := this.parseSelector()
+		if err != nil {
+			return seg, err
+		}
+		seg.selectors = append(seg.selectors, sel)
+		this.skipSpaces()
+		if this.consume("]") {
+			return seg, nil
+		}
+		if !this.consume(",") {
+			return seg, this.errorf("] or , expected")
+		}
+	}
+}
+
+func (this *jpParser) parseSelector() (jpSelector, error) {
+	switch c := this.peek(); {
+	case c == '\'' || c == '"':
+		s, err := this.parseString()
+		return jpSelector{kind: jpName, name: s}, err
+	case c == '*':
+		this.pos++
+		return jpSelector{kind: jpWildcard}, nil
+	case c == '?':
+		this.pos++
+		f, err := this.parseOr()
+		return jpSelector{kind: jpFilter, filter: f}, err
+	case c == '-' || c == ':' || (c >= '0' && c <= '9'):
+		return this.parseIndexOrSlice()
+	}
+	return jpSelector{}, this.errorf("selector expected")
+}
+
+func (this *jpParser) parseIndexOrSlice() (jpSelector, error) {
+	var bounds [3]*int
+	for i := 0; i < 3; i++ {
+		this.skipSpaces()
+		if c := this.peek(); c == '-' || (c >= '0' && c <= '9') {
+			n, err := this.parseInt()
+			if err != nil {
+				return jpSelector{}, err
+			}
+			bounds[i] = &n
+			this.skipSpaces()
+		}
+		if i == 0 && this.peek() != ':' {
+			if bounds[0] == nil {
+				return jpSelector{}, this.errorf("index expected")
+			}
+			return jpSelector{kind: jpIndex, index: *bounds[0]}, nil
+		}
+		if i == 2 || !this.consume(":") {
+			break
+		}
+	}
+	return jpSelector{kind: jpSlice, start: bounds[0], end: bounds[1], step: bounds[2]}, nil
+}
+
+// integers are limited to I-JSON range
+func (this *jpParser) parseInt() (int, error) {
+	start := this.pos
+	this.consume("-")
+	digits := this.pos
+	for this.pos < len(this.s) && this.s[this.pos] >= '0' && this.s[this.pos] <= '9' {
+		this.pos++
+	}
+	text := this.s[start:this.pos]
+	if this.pos == digits || (this.s[digits] == '0' && (this.pos-digits > 1 || digits > start)) {
+		return 0, this.errorf("bad integer %q", text)
+	}
+	n, err := strconv.ParseInt(text, 10, 64)
+	if err != nil || n > 1<<53-1 || n < -(1<<53-1) {
+		return 0, this.errorf("integer out of range %q", text)
+	}
+	return int(n), nil
+}
+
+func (this *jpParser) parseString() (string, error) {
+	quote := this.s[this.pos]
+	this.pos++
+	var b strings.Builder
+	for this.pos < len(this.s) {
+		c := this.s[this.pos]
+		switch {
+		case c == quote:
+			this.pos++
+			return b.String(), nil
+		case c < 0x20:
+			return "", this.errorf("control character in string")
+		case c != '\\':
+			b.WriteByte(c)
+			this.pos++
+			continue
+		}
+
+		this.pos++
+		e := this.peek()
+		this.pos++
+		switch e {
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '/', '\\':
+			b.WriteByte(e)
+		case '\'', '"':
+			if e != quote {
+				return "", this.errorf("bad escape sequence")
+			}
+			b.WriteByte(e)
+		case 'u':
+			r, err := this.parseHex4()
+			if err != nil {
+				return "", err
+			}
+			if utf16.IsSurrogate(r) {
+				if !this.consume(`\u`) {
+					return "", this.errorf("lone surrogate in string")
+				}
+				r2, err := this.parseHex4()
+				if err != nil {
+					return "", err
+				}
+				if r = utf16.DecodeRune(r, r2); r == utf8.RuneError {
+					return "", this.errorf("bad surrogate pair in string")
+				}
+			}
+			b.WriteRune(r)
+		default:
+			return "", this.errorf("bad escape sequence")
+		}
+	}
+	return "", this.errorf("unterminated string")
+}
+
+func (this *jpParser) parseHex4() (rune, error) {
+	if this.pos+4 > len(this.s) {
+		return 0, this.errorf("bad unicode escape")
+	}
+	n, err := strconv.ParseUint(this.s[this.pos:this.pos+4], 16, 32)
+	if err != nil {
+		return 0, this.errorf("bad unicode escape")
+	}
+	this.pos += 4
+	return rune(n), nil
+}
+
+func (this *jpParser) parseOr() (jpLogical, error) {
+	items := jpOr{}
+	for {
+		e, err := this.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+		this.skipSpaces()
+		if !this.consume("||") {
+			break
+		}
+	}
+	if len(items) == 1 {
+		return items[0], nil
+	}
+	return items, nil
+}
+
+func (this *jpParser) parseAnd() (jpLogical, error) {
+	items := jpAnd{}
+	for {
+		e, err := this.parseBasic()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+		this.skipSpaces()
+		if !this.consume("&&") {
+			break
+		}
+	}
+	if len(items) == 1 {
+		return items[0], nil
+	}
+	return items, nil
+}
+
+func (this *jpParser) parseBasic() (jpLogical, error) {
+	this.skipSpaces()
+	if this.consume("!") {
+		this.skipSpaces()
+		var e jpLogical
+		var err error
+		if this.peek() == '(' {
+			e, err = this.parseParen()
+		} else {
+			e, err = this.parseTest()
+		}
+		if err != nil {
+			return nil, err
+		}
+		return jpNot{e}, nil
+	}
+	if this.peek() == '(' {
+		return this.parseParen()
+	}
+
+	start := this.pos
+	left, err := this.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	this.skipSpaces()
+	op := this.parseOperator()
+	if op == "" {
+		this.pos = start
+		return this.parseTest()
+	}
+	l, err := this.comparable(left)
+	if err != nil {
+		return nil, err
+	}
+	this.skipSpaces()
+	right, err := this.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	r, err := this.comparable(right)
+	if err != nil {
+		return nil, err
+	}
+	return jpComparison{op: op, left: l, right: r}, nil
+}
+
+func (this *jpParser) parseParen() (jpLogical, error) {
+	this.pos++
+	e, err := this.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	this.skipSpaces()
+	if !this.consume(")") {
+		return nil, this.errorf(") expected")
+	}
+	return e, nil
+}
+
+// existence test or logical function
+func (this *jpParser) parseTest() (jpLogical, error) {
+	operand, err := this.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	switch o := operand.(type) {
+	case *jpQuery:
+		return jpExists{o}, nil
+	case *jpFunction:
+		if !o.logical() {
+			return nil, this.errorf("%s() result should be compared", o.name)
+		}
+		return jpFunctionTest{o}, nil
+	}
+	return nil, this.errorf("literal should be compared")
+}
+
+func (this *jpParser) parseOperator() string {
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if this.consume(op) {
+			return op
+		}
+	}
+	return ""
+}
+
+func (this *jpParser) comparable(operand interface{}) (jpComparable, error) {
+	switch o := operand.(type) {
+	case *jpQuery:
+		if !o.singular() {
+			return nil, this.errorf("only singular queries can be compared")
+		}
+		return jpSingularQuery{o}, nil
+	case *jpFunction:
+		if o.logical() {
+			return nil, this.errorf("%s() result cannot be compared", o.name)
+		}
+		return o, nil
+	case jpLiteral:
+		return o, nil
+	}
+	return nil, this.errorf("comparable expected")
+}
+
+// returns *jpQuery, *jpFunction or jpLiteral
+func (this *jpParser) parseOperand() (interface{}, error) {
+	this.skipSpaces()
+	c := this.peek()
+	switch {
+	case c == '@' || c == '$':
+		return this.parseQuery()
+	case c == '\'' || c == '"':
+		s, err := this.parseString()
+		return jpLiteral{s}, err
+	case c == '-' || (c >= '0' && c <= '9'):
+		return this.parseNumber()
+	case c >= 'a' && c <= 'z':
+		start := this.pos
+		for this.pos < len(this.s) && (isJPNameChar(this.s[this.pos], false) && this.s[this.pos] < 0x80) {
+			this.pos++
+		}
+		name := this.s[start:this.pos]
+		if this.peek() == '(' {
+			return this.parseFunction(name)
+		}
+		switch name {
+		case "true":
+			return jpLiteral{true}, nil
+		case "false":
+			return jpLiteral{false}, nil
+		case "null":
+			return jpLiteral{nil}, nil
+		}
+		this.pos = start
+	}
+	return nil, this.errorf("unexpected character %q", c)
+}
+
+func (this *jpParser) parseNumber() (interface{}, error) {
+	start := this.pos
+	this.consume("-")
+	digits := this.pos
+	for this.pos < len(this.s) && this.s[this.pos] >= '0' && this.s[this.pos] <= '9' {
+		this.pos++
+	}
+	if this.pos == digits || (this.s[digits] == '0' && this.pos-digits > 1) {
+		return nil, this.errorf("bad number")
+	}
+	if this.consume(".") {
+		frac := this.pos
+		for this.pos < len(this.s) && this.s[this.pos] >= '0' && this.s[this.pos] <= '9' {
+			this.pos++
+		}
+		if this.pos == frac {
+			return nil, this.errorf("bad number")
+		}
+	}
+	if c := this.peek(); c == 'e' || c == 'E' {
+		this.pos++
+		if c := this.peek(); c == '+' || c == '-' {
+			this.pos++
+		}
+		exp := this.pos
+		for this.pos < len(this.s) && this.s[this.pos] >= '0' && this.s[this.pos] <= '9' {
+			this.pos++
+		}
+		if this.pos == exp {
+			return nil, this.errorf("bad number")
+		}
+	}
+	f, err := strconv.ParseFloat(this.s[start:this.pos], 64)
+	if err != nil || math.IsInf(f, 0) {
+		return nil, this.errorf("bad number")
+	}
+	return jpLiteral{f}, nil
+}
+
+func (this *jpParser) parseFunction(name string) (interface{}, error) {
+	spec, known := jpFunctions[name]
+	if !known {
+		return nil, this.errorf("unknown function %s()", name)
+	}
+	this.pos++
+	f := &jpFunction{name: name}
+	for {
+		this.skipSpaces()
+		if this.consume(")") {
+			break
+		}
+		if len(f.args) > 0 && !this.consume(",") {
+			return nil, this.errorf(", or ) expected")
+		}
+		operand, err := this.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if q, ok := operand.(*jpQuery); ok && spec.nodes {
+			f.args = append(f.args, q)
+			continue
+		}
+		if spec.nodes {
+			return nil, this.errorf("%s() expects query argument", name)
+		}
+		arg, err := this.comparable(operand)
+		if err != nil {
+			return nil, err
+		}
+		f.args = append(f.args, arg)
+	}
+	if len(f.args) != spec.args {
+		return nil, this.errorf("%s() expects %d arguments", name, spec.args)
+	}
+
+	if spec.logical {
+		if lit, ok := f.args[1].(jpLiteral); ok {
+			pattern, isString := lit.v.(string)
+			if !isString {
+				return nil, this.errorf("%s() pattern should be string", name)
+			}
+			re, err := compileJPRegexp(pattern, name == "match")
+			if err != nil {
+				return nil, this.errorf("bad regular expression: %s", err)
+			}
+			f.re = re
+		}
+	}
+	return f, nil
+}
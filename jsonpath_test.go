@@ -0,0 +1,73 @@
+package jsonlight
+
+import (
+	"reflect"
+	"testing"
+)
+
+const jsonPathStore = `{"store":{
+	"book":[
+		{"category":"reference","author":"Nigel Rees","title":"Sayings of the Century","price":8.95},
+		{"category":"fiction","author":"Evelyn Waugh","title":"Sword of Honour","price":12.99},
+		{"category":"fiction","author":"Herman Melville","title":"Moby Dick","isbn":"0-553-21311-3","price":8.99},
+		{"category":"fiction","author":"J. R. R. Tolkien","title":"The Lord of the Rings","isbn":"0-395-19395-8","price":22.99}
+	],
+	"bicycle":{"color":"red","price":399}
+}}`
+
+func TestJSONPathQuery(t *testing.T) {
+	o := NewObjectOrDie(jsonPathStore)
+
+	cases := []struct {
+		expr     string
+		expected string
+	}{
+		{`$.store.book[*].author`, `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{`$..author`, `["Nigel Rees","Evelyn Waugh","Herman Melville","J. R. R. Tolkien"]`},
+		{`$.store..price`, `[399,8.95,12.99,8.99,22.99]`},
+		{`$..book[2].title`, `["Moby Dick"]`},
+		{`$..book[-1].title`, `["The Lord of the Rings"]`},
+		{`$..book[0,1].price`, `[8.95,12.99]`},
+		{`$..book[:2].price`, `[8.95,12.99]`},
+		{`$..book[::-2].price`, `[22.99,12.99]`},
+		{`$..book[?@.isbn].title`, `["Moby Dick","The Lord of the Rings"]`},
+		{`$..book[?@.price<10].title`, `["Sayings of the Century","Moby Dick"]`},
+		{`$..book[?@.price > $.store.bicycle.price / 100 || @.category == 'reference'].price`, ``},
+		{`$..book[?!(@.category == "fiction") && length(@.title) > 5].author`, `["Nigel Rees"]`},
+		{`$..book[?match(@.author, 'H.*')].title`, `["Moby Dick"]`},
+		{`$..book[?search(@.title, 'of')].price`, `[8.95,12.99,22.99]`},
+		{`$.store[?count(@.*) == 2]`, `[{"color":"red","price":399}]`},
+		{`$["store"]['bicycle'].color`, `["red"]`},
+		{`$.store.missing`, `[]`},
+	}
+	for _, c := range cases {
+		res, err := o.Query(c.expr)
+		if c.expected == "" {
+			if err == nil {
+				t.Errorf("%s: expected error", c.expr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.expr, err)
+			continue
+		}
+		if s := res.ToString(); s != c.expected {
+			t.Errorf("%s:\nexpected %s\ngot      %s", c.expr, c.expected, s)
+		}
+	}
+
+	paths, err := o.QueryPaths(`$..book[?@.price > 20]['title']`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{`$['store']['book'][3]['title']`}; !reflect.DeepEqual(paths, expected) {
+		t.Errorf("unexpected paths %v", paths)
+	}
+
+	for _, bad := range []string{`store`, `$.`, `$[01]`, `$[?@.a == @.*]`, `$[?length(@)]`, `$['a'`} {
+		if _, err := CompileJSONPath(bad); err == nil {
+			t.Errorf("%s: expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package jsonlight
+
+import (
+	"io"
+	"iter"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// read-only object which resolves keys through a stack of layers, e.g. defaults, env and local overrides.
+// later layers win, objects are merged recursively the same way as DeepMergeWithOptions does.
+// values are merged copies, so changes should be made to layers themselves
+type LayeredObject struct {
+	Options MergeOptions
+	layers  []Layer
+}
+
+type Layer struct {
+	Name   string
+	Object IReadonlyObject
+}
+
+func NewLayeredObject(layers ...Layer) *LayeredObject {
+	return &LayeredObject{layers: layers}
+}
+
+// puts layer on top of the stack
+func (this *LayeredObject) AddLayer(name string, o IReadonlyObject) *LayeredObject {
+	this.layers = append(this.layers, Layer{Name: name, Object: o})
+	return this
+}
+
+func (this *LayeredObject) Layers() []Layer {
+	return append([]Layer(nil), this.layers...)
+}
+
+// all layers merged into new object
+func (this *LayeredObject) Merged() IObject {
+	res, _ := this.merge(false)
+	return res
+}
+
+func (this *LayeredObject) merge(track bool) (IObject, map[string]int) {
+	res := NewOrderedObject()
+	m := merger{opts: this.Options}
+	if track {
+		m.sources = map[string]int{}
+	}
+	for i, l := range this.layers {
+		if l.Object != nil {
+			m.layer = i
+			m.mergeObject(res, l.Object, "")
+		}
+	}
+	return res, m.sources
+}
+
+// merged value of single key, without merging whole object
+func (this *LayeredObject) single(key string) IObject {
+	res := NewOrderedObject()
+	m := merger{opts: this.Options}
+	for i, l := range this.layers {
+		if l.Object == nil {
+			continue
+		}
+		if v, ok := l.Object.Get(key); ok {
+			m.layer = i
+			m.mergeKey(res, key, v, "/"+EscapePointerToken(key))
+		}
+	}
+	return res
+}
+
+// name of layer which supplied value at JSON pointer, e.g. "/db/host".
+// for objects merged from several layers it is the layer which created the object
+func (this *LayeredObject) Source(pointer string) (string, bool) {
+	merged, sources := this.merge(true)
+	if pointer == "" || !merged.HasPointer(pointer) {
+		return "", false
+	}
+	for p := pointer; p != ""; p = p[:strings.LastIndex(p, "/")] {
+		if i, ok := sources[p]; ok {
+			return this.layers[i].Name, true
+		}
+	}
+	return "", false
+}
+
+func (this *LayeredObject) ToReadonlyObject() IReadonlyObject {
+	return this
+}
+
+func (this *LayeredObject) Length() int {
+	return len(this.Keys())
+}
+
+func (this *LayeredObject) Get(key string) (interface{}, bool) {
+	return this.single(key).Get(key)
+}
+
+func (this *LayeredObject) GetBoolean(key string) (bool, error) {
+	return this.single(key).GetBoolean(key)
+}
+
+func (this *LayeredObject) GetDouble(key string) (float64, error) {
+	return this.single(key).GetDouble(key)
+}
+
+func (this *LayeredObject) GetInt(key string) (int, error) {
+	return this.single(key).GetInt(key)
+}
+
+func (this *LayeredObject) GetArray(key string) (IArray, error) {
+	return this.single(key).GetArray(key)
+}
+
+func (this *LayeredObject) GetObject(key string) (IObject, error) {
+	return this.single(key).GetObject(key)
+}
+
+func (this *LayeredObject) GetLong(key string) (int64, error) {
+	return this.single(key).GetLong(key)
+}
+
+func (this *LayeredObject) GetString(key string) (string, error) {
+	return this.single(key).GetString(key)
+}
+
+func (this *LayeredObject) GetBigInt(key string) (*big.Int, error) {
+	return this.single(key).GetBigInt(key)
+}
+
+func (this *LayeredObject) GetUint64(key string) (uint64, error) {
+	return this.single(key).GetUint64(key)
+}
+
+func (this *LayeredObject) GetTime(key string, layouts ...string) (time.Time, error) {
+	return this.single(key).GetTime(key, layouts...)
+}
+
+func (this *LayeredObject) GetDuration(key string) (time.Duration, error) {
+	return this.single(key).GetDuration(key)
+}
+
+func (this *LayeredObject) GetBytes(key string) ([]byte, error) {
+	return this.single(key).GetBytes(key)
+}
+
+func (this *LayeredObject) Has(key string) bool {
+	return this.single(key).Has(key)
+}
+
+func (this *LayeredObject) IsNull(key string) bool {
+	return this.single(key).IsNull(key)
+}
+
+func (this *LayeredObject) Opt(key string, defaultvalue ...interface{}) interface{} {
+	return this.single(key).Opt(key, defaultvalue...)
+}
+
+func (this *LayeredObject) OptBoolean(key string, defaultvalue ...bool) bool {
+	return this.single(key).OptBoolean(key, defaultvalue...)
+}
+
+func (this *LayeredObject) OptDouble(key string, defaultvalue ...float64) float64 {
+	return this.single(key).OptDouble(key, defaultvalue...)
+}
+
+func (this *LayeredObject) OptInt(key string, defaultvalue ...int) int {
+	return this.single(key).OptInt(key, defaultvalue...)
+}
+
+func (this *LayeredObject) OptArray(key string, defaultvalue ...IArray) IArray {
+	return this.single(key).OptArray(key, defaultvalue...)
+}
+
+func (this *LayeredObject) OptObject(key string, defaultvalue ...IObject) IObject {
+	return this.single(key).OptObject(key, defaultvalue...)
+}
+
+func (this *LayeredObject) OptLong(key string, defaultvalue ...int64) int64 {
+	return this.single(key).OptLong(key, defaultvalue...)
+}
+
+func (this *LayeredObject) OptString(key string, defaultvalue ...string) string {
+	return this.single(key).OptString(key, defaultvalue...)
+}
+
+func (this *LayeredObject) OptTime(key string, defaultvalue ...time.Time) time.Time {
+	return this.single(key).OptTime(key, defaultvalue...)
+}
+
+func (this *LayeredObject) OptDuration(key string, defaultvalue ...time.Duration) time.Duration {
+	return this.single(key).OptDuration(key, defaultvalue...)
+}
+
+func (this *LayeredObject) OptBytes(key string, defaultvalue ...[]byte) []byte {
+	return this.single(key).OptBytes(key, defaultvalue...)
+}
+
+func (this *LayeredObject) ToString(indentFactor ...int) string {
+	return this.Merged().ToString(indentFactor...)
+}
+
+func (this *LayeredObject) ToByteArray(indentFactor ...int) []byte {
+	return this.Merged().ToByteArray(indentFactor...)
+}
+
+func (this *LayeredObject) Write(writer *io.Writer) {
+	this.Merged().Write(writer)
+}
+
+func (this *LayeredObject) ToArray(names ...string) IArray {
+	return this.Merged().ToArray(names...)
+}
+
+func (this *LayeredObject) ToMap() map[string]interface{} {
+	return this.Merged().ToMap()
+}
+
+func (this *LayeredObject) Keys() []string {
+	return this.Merged().Keys()
+}
+
+func (this *LayeredObject) All() iter.Seq2[string, interface{}] {
+	return this.Merged().All()
+}
+
+func (this *LayeredObject) SortedAll() iter.Seq2[string, interface{}] {
+	return this.Merged().SortedAll()
+}
+
+func (this *LayeredObject) Objects() iter.Seq2[string, IObject] {
+	return this.Merged().Objects()
+}
+
+func (this *LayeredObject) Arrays() iter.Seq2[string, IArray] {
+	return this.Merged().Arrays()
+}
+
+func (this *LayeredObject) Strings() iter.Seq2[string, string] {
+	return this.Merged().Strings()
+}
+
+func (this *LayeredObject) Walk() iter.Seq2[string, interface{}] {
+	return this.Merged().Walk()
+}
+
+func (this *LayeredObject) GetPointer(pointer string) (interface{}, bool) {
+	return this.Merged().GetPointer(pointer)
+}
+
+func (this *LayeredObject) HasPointer(pointer string) bool {
+	return this.Merged().HasPointer(pointer)
+}
+
+func (this *LayeredObject) GetBooleanPointer(pointer string) (bool, error) {
+	return this.Merged().GetBooleanPointer(pointer)
+}
+
+func (this *LayeredObject) GetDoublePointer(pointer string) (float64, error) {
+	return this.Merged().GetDoublePointer(pointer)
+}
+
+func (this *LayeredObject) GetIntPointer(pointer string) (int, error) {
+	return this.Merged().GetIntPointer(pointer)
+}
+
+func (this *LayeredObject) GetArrayPointer(pointer string) (IArray, error) {
+	return this.Merged().GetArrayPointer(pointer)
+}
+
+func (this *LayeredObject) GetObjectPointer(pointer string) (IObject, error) {
+	return this.Merged().GetObjectPointer(pointer)
+}
+
+func (this *LayeredObject) GetLongPointer(pointer string) (int64, error) {
+	return this.Merged().GetLongPointer(pointer)
+}
+
+func (this *LayeredObject) GetStringPointer(pointer string) (string, error) {
+	return this.Merged().GetStringPointer(pointer)
+}
+
+func (this *LayeredObject) OptBooleanPointer(pointer string, defaultvalue ...bool) bool {
+	return this.Merged().OptBooleanPointer(pointer, defaultvalue...)
+}
+
+func (this *LayeredObject) OptDoublePointer(pointer string, defaultvalue ...float64) float64 {
+	return this.Merged().OptDoublePointer(pointer, defaultvalue...)
+}
+
+func (this *LayeredObject) OptIntPointer(pointer string, defaultvalue ...int) int {
+	return this.Merged().OptIntPointer(pointer, defaultvalue...)
+}
+
+func (this *LayeredObject) OptArrayPointer(pointer string, defaultvalue ...IArray) IArray {
+	return this.Merged().OptArrayPointer(pointer, defaultvalue...)
+}
+
+func (this *LayeredObject) OptObjectPointer(pointer string, defaultvalue ...IObject) IObject {
+	return this.Merged().OptObjectPointer(pointer, defaultvalue...)
+}
+
+func (this *LayeredObject) OptLongPointer(pointer string, defaultvalue ...int64) int64 {
+	return this.Merged().OptLongPointer(pointer, defaultvalue...)
+}
+
+func (this *LayeredObject) OptStringPointer(pointer string, defaultvalue ...string) string {
+	return this.Merged().OptStringPointer(pointer, defaultvalue...)
+}
+
+func (this *LayeredObject) Query(expr string) (IArray, error) {
+	return this.Merged().Query(expr)
+}
+
+func (this *LayeredObject) QueryPaths(expr string) ([]string, error) {
+	return this.Merged().QueryPaths(expr)
+}
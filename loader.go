@@ -0,0 +1,292 @@
+package jsonlight
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// loading of configuration trees split over several local files:
+//   - {"$include": "other.json"} or {"$include": ["a.json", "b.json"]} is replaced by included
+//     objects merged together, other keys of the node are merged on top of them
+//   - {"$ref": "file.json#/pointer"} is replaced by value at pointer, "#/pointer" refers to the same file.
+//     pointer addresses the file as it is written, before its own nodes are resolved
+// file names are relative to the file which has the node, every file should have object at its root
+
+type Loader struct {
+	// parse files into ordered objects
+	Ordered bool
+	// how included objects are merged
+	MergeOptions MergeOptions
+
+	files   map[string]IObject
+	active  map[string]bool
+	stack   []string
+	result  IObject
+	sources sourceMap
+}
+
+// error at pointer inside of file, e.g. $include of missing file
+type LoadError struct {
+	File    string
+	Pointer string
+	Err     error
+}
+
+func (e LoadError) Error() string {
+	return fmt.Sprintf("%s#%s: %v", e.File, e.Pointer, e.Err)
+}
+
+func (e LoadError) Unwrap() error { return e.Err }
+
+type IncludeCycleError struct {
+	// file#pointer entries, the last one is the same as one of previous
+	Chain []string
+}
+
+func (e IncludeCycleError) Error() string {
+	return "include cycle: " + strings.Join(e.Chain, " -> ")
+}
+
+func NewLoader() *Loader {
+	return &Loader{}
+}
+
+// loads file with default options
+func LoadFile(path string) (IObject, error) {
+	return NewLoader().Load(path)
+}
+
+func (this *Loader) Load(path string) (IObject, error) {
+	this.files, this.active, this.stack = map[string]IObject{}, map[string]bool{}, nil
+	v, srcs, err := this.load(path, "")
+	if err != nil {
+		return nil, err
+	}
+	o, ok := ObjectValue(v)
+	if !ok {
+		return nil, typeMismatch(nil, "object", v)
+	}
+	this.result, this.sources = o, srcs
+	return o, nil
+}
+
+// absolute name of file which supplied value at pointer of the last loaded object
+func (this *Loader) Source(pointer string) (string, bool) {
+	if this.result == nil || (pointer != "" && !this.result.HasPointer(pointer)) {
+		return "", false
+	}
+	for p := pointer; ; p = p[:strings.LastIndex(p, "/")] {
+		if f, ok := this.sources[p]; ok {
+			return f, true
+		}
+		if p == "" {
+			return "", false
+		}
+	}
+}
+
+func (this *Loader) load(path string, pointer string) (interface{}, sourceMap, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := abs + "#" + pointer
+	if this.active[key] {
+		i := 0
+		for this.stack[i] != key {
+			i++
+		}
+		return nil, nil, IncludeCycleError{Chain: append(append([]string(nil), this.stack[i:]...), key)}
+	}
+
+	doc, ok := this.files[abs]
+	if !ok {
+		if this.Ordered {
+			doc, err, _ = NewOrderedObjectFromFile(abs, 0)
+		} else {
+			doc, err, _ = NewObjectFromFile(abs, 0)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		this.files[abs] = doc
+	}
+	var v interface{} = doc
+	if pointer != "" {
+		if v, ok = doc.GetPointer(pointer); !ok {
+			return nil, nil, notFound(pointer)
+		}
+	}
+
+	this.active[key] = true
+	this.stack = append(this.stack, key)
+	defer func() {
+		delete(this.active, key)
+		this.stack = this.stack[:len(this.stack)-1]
+	}()
+	return this.resolve(v, abs, pointer)
+}
+
+func (this *Loader) newObject() IObject {
+	if this.Ordered {
+		return NewOrderedObject()
+	}
+	return NewObjectOrNil()
+}
+
+// copy of v with nodes resolved, at is pointer of v inside of file
+func (this *Loader) resolve(v interface{}, file string, at string) (interface{}, sourceMap, error) {
+	if o, ok := ReadonlyObjectValue(v); ok {
+		if o.Has("$include") || o.Has("$ref") {
+			res, srcs, err := this.directive(o, file, at)
+			if err != nil {
+				return nil, nil, LoadError{File: file, Pointer: at, Err: err}
+			}
+			return res, srcs, nil
+		}
+		res := this.newObject()
+		srcs := sourceMap{"": file}
+		for _, k := range stableKeys(o) {
+			x, _ := o.Get(k)
+			token := "/" + EscapePointerToken(k)
+			rx, rs, err := this.resolve(x, file, at+token)
+			if err != nil {
+				return nil, nil, err
+			}
+			if _, err := res.Put(k, rx); err != nil {
+				return nil, nil, err
+			}
+			srcs.add(token, rs)
+		}
+		return res, srcs, nil
+	}
+
+	if s, ok := SliceValue(v); ok {
+		res := make([]interface{}, len(s))
+		srcs := sourceMap{"": file}
+		for i, x := range s {
+			token := fmt.Sprintf("/%d", i)
+			rx, rs, err := this.resolve(x, file, at+token)
+			if err != nil {
+				return nil, nil, err
+			}
+			res[i] = rx
+			srcs.add(token, rs)
+		}
+		return res, srcs, nil
+	}
+
+	return v, sourceMap{"": file}, nil
+}
+
+func (this *Loader) directive(o IReadonlyObject, file string, at string) (interface{}, sourceMap, error) {
+	var layers []interface{}
+	var layerSrcs []sourceMap
+	add := func(name string, pointer string) error {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(filepath.Dir(file), name)
+		}
+		v, srcs, err := this.load(name, pointer)
+		if err != nil {
+			return err
+		}
+		layers, layerSrcs = append(layers, v), append(layerSrcs, srcs)
+		return nil
+	}
+
+	inc, hasInclude := o.Get("$include")
+	ref, hasRef := o.Get("$ref")
+	switch {
+	case hasInclude && hasRef:
+		return nil, nil, errors.New("$include and $ref cannot be used together")
+	case hasRef:
+		s, ok := ref.(string)
+		if !ok {
+			return nil, nil, typeMismatch("$ref", "string", ref)
+		}
+		name, pointer, _ := strings.Cut(s, "#")
+		if name == "" {
+			name = file
+		}
+		if err := add(name, pointer); err != nil {
+			return nil, nil, err
+		}
+	default:
+		names := []interface{}{inc}
+		if s, ok := SliceValue(inc); ok {
+			names = s
+		}
+		for _, n := range names {
+			s, ok := n.(string)
+			if !ok {
+				return nil, nil, typeMismatch("$include", "string or array of strings", inc)
+			}
+			if err := add(s, ""); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	rest := this.newObject()
+	for _, k := range o.Keys() {
+		if k != "$include" && k != "$ref" {
+			x, _ := o.Get(k)
+			rest.Put(k, x)
+		}
+	}
+	if rest.Length() == 0 && len(layers) == 1 {
+		return layers[0], layerSrcs[0], nil
+	}
+	rv, rs, err := this.resolve(rest, file, at)
+	if err != nil {
+		return nil, nil, err
+	}
+	layers, layerSrcs = append(layers, rv), append(layerSrcs, rs)
+
+	res := this.newObject()
+	m := merger{opts: this.MergeOptions, sources: map[string]int{}}
+	for i, l := range layers {
+		lo, ok := ReadonlyObjectValue(l)
+		if !ok {
+			return nil, nil, fmt.Errorf("%s cannot be merged with other keys", kindOf(l))
+		}
+		m.layer = i
+		if err := m.mergeObject(res, lo, ""); err != nil {
+			return nil, nil, err
+		}
+	}
+	return res, composeSources(file, m.sources, layerSrcs), nil
+}
+
+// JSON pointer relative to some value -> file
+type sourceMap map[string]string
+
+func (this sourceMap) add(prefix string, other sourceMap) {
+	for p, f := range other {
+		this[prefix+p] = f
+	}
+}
+
+// sources of merged object, merged[p] tells which layer has written value at p
+func composeSources(file string, merged map[string]int, layers []sourceMap) sourceMap {
+	paths := make([]string, 0, len(merged))
+	for p := range merged {
+		paths = append(paths, p)
+	}
+	// parents first, so values written later into merged objects win
+	sort.Slice(paths, func(i, j int) bool {
+		return strings.Count(paths[i], "/") < strings.Count(paths[j], "/")
+	})
+	res := sourceMap{"": file}
+	for _, p := range paths {
+		for q, f := range layers[merged[p]] {
+			if q == p || strings.HasPrefix(q, p+"/") {
+				res[q] = f
+			}
+		}
+	}
+	return res
+}
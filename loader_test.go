@@ -0,0 +1,93 @@
+package jsonlight
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoader(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.json": `{
+			"$include": ["base.json", "conf/env.json"],
+			"db": {"port": 1},
+			"limits": {"$ref": "conf/limits.json#/prod"},
+			"name": {"$ref": "#/meta/name"},
+			"meta": {"name": "app"}
+		}`,
+		"base.json":        `{"db": {"host": "localhost", "port": 5432}, "debug": false}`,
+		"conf/env.json":    `{"db": {"host": {"$ref": "hosts.json#/list/0"}}, "debug": true}`,
+		"conf/hosts.json":  `{"list": ["db.internal"]}`,
+		"conf/limits.json": `{"prod": {"rps": 100, "burst": [1, 2]}}`,
+	})
+
+	l := NewLoader()
+	l.Ordered = true
+	o, err := l.Load(filepath.Join(dir, "main.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"db":{"host":"db.internal","port":1},"debug":true,"limits":{"rps":100,"burst":[1,2]},"name":"app","meta":{"name":"app"}}`
+	if s := o.ToString(); s != want {
+		t.Errorf("got %s", s)
+	}
+
+	sources := map[string]string{
+		"/db/host":        "conf/hosts.json",
+		"/db/port":        "main.json",
+		"/debug":          "conf/env.json",
+		"/limits/burst/1": "conf/limits.json",
+		"/name":           "main.json",
+		"":                "main.json",
+	}
+	for p, want := range sources {
+		if got, ok := l.Source(p); !ok || got != filepath.Join(dir, want) {
+			t.Errorf("source of %q: %s", p, got)
+		}
+	}
+	if _, ok := l.Source("/missing"); ok {
+		t.Error("missing value should have no source")
+	}
+}
+
+func TestLoaderErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"a.json":       `{"x": {"$include": "b.json"}}`,
+		"b.json":       `{"y": [{"$include": "a.json"}]}`,
+		"self.json":    `{"a": {"$ref": "#/b"}, "b": {"$ref": "#/a"}}`,
+		"missing.json": `{"a": {"$include": "nope.json"}}`,
+		"bad.json":     `{"a": {"$ref": "#/b", "c": 1}, "b": 5}`,
+	})
+
+	var cycle IncludeCycleError
+	_, err := LoadFile(filepath.Join(dir, "a.json"))
+	if !errors.As(err, &cycle) || len(cycle.Chain) != 3 {
+		t.Errorf("include cycle: %v", err)
+	}
+	_, err = LoadFile(filepath.Join(dir, "self.json"))
+	if !errors.As(err, &cycle) {
+		t.Errorf("ref cycle: %v", err)
+	}
+
+	var le LoadError
+	_, err = LoadFile(filepath.Join(dir, "missing.json"))
+	if !errors.As(err, &le) || le.Pointer != "/a" || le.File != filepath.Join(dir, "missing.json") || !os.IsNotExist(errors.Unwrap(le)) {
+		t.Errorf("missing include: %v", err)
+	}
+	if _, err = LoadFile(filepath.Join(dir, "bad.json")); err == nil {
+		t.Error("scalar $ref with other keys should fail")
+	}
+}
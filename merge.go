@@ -0,0 +1,207 @@
+package jsonlight
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// recursive merge of configuration-like objects, see also MergePatch
+
+type ArrayMergeStrategy int
+
+const (
+	// array from source replaces destination one
+	ArrayReplace ArrayMergeStrategy = iota
+	// elements of source array are appended to destination one
+	ArrayAppend
+	// elements with the same index are merged, extra elements are appended
+	ArrayMergeByIndex
+	// object elements with equal MergeOptions.ArrayKey field are merged, other elements are appended
+	ArrayMergeByKey
+)
+
+type MergeOptions struct {
+	Arrays ArrayMergeStrategy
+	// field which identifies elements for ArrayMergeByKey, e.g. "id" or "name"
+	ArrayKey string
+	// null in source removes the key instead of being stored
+	NullDeletes bool
+}
+
+// merges srcs into dst one by one: objects are merged recursively,
+// arrays are replaced, other values of later sources win
+func DeepMerge(dst IObject, srcs ...IReadonlyObject) error {
+	return DeepMergeWithOptions(dst, MergeOptions{}, srcs...)
+}
+
+func DeepMergeWithOptions(dst IObject, opts MergeOptions, srcs ...IReadonlyObject) error {
+	if dst == nil {
+		return errors.New("DeepMerge called with nil param")
+	}
+	m := merger{opts: opts}
+	for i, src := range srcs {
+		if src == nil {
+			continue
+		}
+		m.layer = i
+		if err := m.mergeObject(dst, src, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type merger struct {
+	opts  MergeOptions
+	layer int
+	// JSON pointer -> index of source which wrote value there, nil if sources are not tracked
+	sources map[string]int
+}
+
+func (this *merger) mergeObject(dst IObject, src IReadonlyObject, path string) error {
+	for _, k := range stableKeys(src) {
+		sv, _ := src.Get(k)
+		if err := this.mergeKey(dst, k, sv, path+"/"+EscapePointerToken(k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this *merger) mergeKey(dst IObject, k string, sv interface{}, path string) error {
+	dv, exists := dst.Get(k)
+	if isNil(&sv) && this.opts.NullDeletes {
+		dst.Remove(k)
+		this.forget(path)
+		return nil
+	}
+
+	if so, ok := ReadonlyObjectValue(sv); ok {
+		do, err := dst.GetObject(k)
+		if err != nil {
+			// not an object yet, so source is merged into empty one
+			var empty interface{} = map[string]interface{}{}
+			if _, ordered := dst.(*OrderedObject); ordered {
+				empty = NewOrderedObject()
+			}
+			if _, err := dst.Put(k, empty); err != nil {
+				return err
+			}
+			this.replace(path, dv)
+			if do, err = dst.GetObject(k); err != nil {
+				return err
+			}
+		}
+		return this.mergeObject(do, so, path)
+	}
+
+	ds, dok := SliceValue(dv)
+	ss, sok := SliceValue(sv)
+	if exists && dok && sok {
+		_, err := dst.Put(k, this.mergeArray(ds, ss, path))
+		return err
+	}
+
+	if _, err := dst.Put(k, DeepCopy(sv)); err != nil {
+		return err
+	}
+	this.replace(path, dv)
+	return nil
+}
+
+func (this *merger) mergeArray(ds, ss []interface{}, path string) []interface{} {
+	res := append([]interface{}(nil), ds...)
+	add := func(v interface{}) {
+		res = append(res, DeepCopy(v))
+		this.record(path + "/" + strconv.Itoa(len(res)-1))
+	}
+
+	switch this.opts.Arrays {
+	case ArrayAppend:
+		for _, v := range ss {
+			add(v)
+		}
+	case ArrayMergeByIndex:
+		for i, v := range ss {
+			if i < len(res) {
+				res[i] = this.mergeElement(res[i], v, path+"/"+strconv.Itoa(i))
+			} else {
+				add(v)
+			}
+		}
+	case ArrayMergeByKey:
+		for _, v := range ss {
+			if i := this.findByKey(res, v); i >= 0 {
+				res[i] = this.mergeElement(res[i], v, path+"/"+strconv.Itoa(i))
+			} else {
+				add(v)
+			}
+		}
+	default:
+		res = DeepCopy(ss).([]interface{})
+		this.replace(path, ds)
+	}
+	return res
+}
+
+// index of element of s with the same ArrayKey field as v, or -1
+func (this *merger) findByKey(s []interface{}, v interface{}) int {
+	vo, ok := ReadonlyObjectValue(v)
+	if !ok || this.opts.ArrayKey == "" {
+		return -1
+	}
+	kv, ok := vo.Get(this.opts.ArrayKey)
+	if !ok {
+		return -1
+	}
+	for i, x := range s {
+		if xo, ok := ReadonlyObjectValue(x); ok {
+			if xk, ok := xo.Get(this.opts.ArrayKey); ok && ValuesEqual(xk, kv) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// array elements are merged the same way as object values
+func (this *merger) mergeElement(dv, sv interface{}, path string) interface{} {
+	holder := JSONObject{"v": dv}
+	if err := this.mergeKey(&holder, "v", sv, path); err != nil {
+		return dv
+	}
+	return holder["v"]
+}
+
+func (this *merger) record(path string) {
+	if this.sources != nil {
+		this.sources[path] = this.layer
+	}
+}
+
+// value at path is written by current source, so whatever was below previous value is gone
+func (this *merger) replace(path string, prev interface{}) {
+	if this.sources == nil {
+		return
+	}
+	if _, ok := ReadonlyObjectValue(prev); ok {
+		this.forget(path)
+	} else if _, ok := SliceValue(prev); ok {
+		this.forget(path)
+	}
+	this.record(path)
+}
+
+// drops sources of path and everything below it
+func (this *merger) forget(path string) {
+	if this.sources == nil {
+		return
+	}
+	delete(this.sources, path)
+	for p := range this.sources {
+		if strings.HasPrefix(p, path+"/") {
+			delete(this.sources, p)
+		}
+	}
+}
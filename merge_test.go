@@ -0,0 +1,107 @@
+package jsonlight
+
+import (
+	"testing"
+)
+
+func TestDeepMerge(t *testing.T) {
+	dst := NewObjectOrDie(`{"db":{"host":"localhost","port":5432},"tags":["a"],"debug":false}`)
+	err := DeepMerge(dst,
+		NewObjectOrDie(`{"db":{"port":6543,"opts":{"ssl":true}},"tags":["b"]}`),
+		nil,
+		NewObjectOrDie(`{"debug":true,"db":{"host":"db"}}`),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NewObjectOrDie(`{"db":{"host":"db","port":6543,"opts":{"ssl":true}},"tags":["b"],"debug":true}`)
+	if !ValuesEqual(dst, want) {
+		t.Errorf("got %s", dst.ToString())
+	}
+	if DeepMerge(nil) == nil {
+		t.Error("nil dst should fail")
+	}
+}
+
+func TestDeepMergeArrays(t *testing.T) {
+	base := `{"a":[{"id":1,"v":1},{"id":2,"v":2},3],"x":{"y":1}}`
+	src := NewObjectOrDie(`{"a":[{"id":2,"w":2},{"id":5},4],"x":null}`)
+	cases := []struct {
+		opts MergeOptions
+		want string
+	}{
+		{MergeOptions{}, `{"a":[{"id":2,"w":2},{"id":5},4],"x":null}`},
+		{MergeOptions{Arrays: ArrayAppend}, `{"a":[{"id":1,"v":1},{"id":2,"v":2},3,{"id":2,"w":2},{"id":5},4],"x":null}`},
+		{MergeOptions{Arrays: ArrayMergeByIndex}, `{"a":[{"id":2,"v":1,"w":2},{"id":5,"v":2},4],"x":null}`},
+		{MergeOptions{Arrays: ArrayMergeByKey, ArrayKey: "id", NullDeletes: true}, `{"a":[{"id":1,"v":1},{"id":2,"v":2,"w":2},3,{"id":5},4]}`},
+	}
+	for i, c := range cases {
+		dst := NewObjectOrDie(base)
+		if err := DeepMergeWithOptions(dst, c.opts, src); err != nil {
+			t.Fatal(err)
+		}
+		if !ValuesEqual(dst, NewObjectOrDie(c.want)) {
+			t.Errorf("case %d: got %s", i, dst.ToString())
+		}
+	}
+	if s := src.OptArray("a").ToString(); s != `[{"id":2,"w":2},{"id":5},4]` {
+		t.Errorf("source changed: %s", s)
+	}
+}
+
+func TestLayeredObject(t *testing.T) {
+	defaults, _ := NewOrderedObjectFromString(`{"name":"app","db":{"host":"localhost","port":5432},"plugins":[{"name":"log"}]}`)
+	env := NewObjectOrDie(`{"db":{"host":"db.internal"},"plugins":[{"name":"metrics"}]}`)
+	local := NewObjectOrDie(`{"db":{"port":1}}`)
+
+	l := NewLayeredObject(Layer{"defaults", defaults}, Layer{"env", env})
+	l.AddLayer("local", local)
+	l.Options.Arrays = ArrayAppend
+
+	if s := l.OptString("name"); s != "app" {
+		t.Errorf("name: %s", s)
+	}
+	db := l.OptObject("db")
+	if db.OptString("host") != "db.internal" || db.OptInt("port") != 1 {
+		t.Errorf("db: %s", db.ToString())
+	}
+	if n := l.OptIntPointer("/db/port"); n != 1 {
+		t.Errorf("pointer: %d", n)
+	}
+	if _, err := l.GetInt("name"); err == nil {
+		t.Error("type errors should be reported")
+	}
+	if l.Has("missing") || l.Length() != 3 {
+		t.Errorf("keys: %v", l.Keys())
+	}
+	if s := l.ToString(); s != `{"name":"app","db":{"host":"db.internal","port":1},"plugins":[{"name":"log"},{"name":"metrics"}]}` {
+		t.Errorf("merged: %s", s)
+	}
+
+	sources := map[string]string{
+		"/name":           "defaults",
+		"/db/host":        "env",
+		"/db/port":        "local",
+		"/db":             "defaults",
+		"/plugins/0/name": "defaults",
+		"/plugins/1/name": "env",
+	}
+	for p, want := range sources {
+		if got, ok := l.Source(p); !ok || got != want {
+			t.Errorf("source of %s: %s", p, got)
+		}
+	}
+	if _, ok := l.Source("/db/missing"); ok {
+		t.Error("missing value should have no source")
+	}
+
+	// layers are live, merged values are copies
+	local.Put("name", "override")
+	l.OptObject("db").Put("host", "changed")
+	if l.OptString("name") != "override" || l.OptObject("db").OptString("host") != "db.internal" {
+		t.Errorf("after changes: %s", l.ToString())
+	}
+	if src, _ := l.Source("/name"); src != "local" {
+		t.Errorf("source after override: %s", src)
+	}
+}
@@ -0,0 +1,78 @@
+package jsonlight
+
+import (
+	"errors"
+	"sort"
+)
+
+// JSON Merge Patch (RFC 7386)
+
+// merges patch into target recursively: null removes member,
+// objects are merged, any other value replaces existing one
+func MergePatch(target IObject, patch IObject) error {
+	if target == nil || patch == nil {
+		return errors.New("MergePatch called with nil param")
+	}
+	for _, k := range patch.Keys() {
+		pv, _ := patch.Get(k)
+		if isNil(&pv) {
+			target.Remove(k)
+			continue
+		}
+		po, ok := ObjectValue(pv)
+		if !ok {
+			if _, err := target.Put(k, DeepCopy(pv)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		to, err := target.GetObject(k)
+		if err != nil {
+			// not an object yet, so patch is merged into empty one
+			var empty interface{} = map[string]interface{}{}
+			if _, ordered := target.(*OrderedObject); ordered {
+				empty = NewOrderedObject()
+			}
+			if _, err := target.Put(k, empty); err != nil {
+				return err
+			}
+			if to, err = target.GetObject(k); err != nil {
+				return err
+			}
+		}
+		if err := MergePatch(to, po); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// makes merge patch which turns old into new.
+// note that arrays are always replaced as a whole
+// and null values of new object cannot be expressed
+func CreateMergePatch(oldo IObject, newo IObject) IObject {
+	res := NewOrderedObject()
+	keys := oldo.Keys()
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !newo.Has(k) {
+			res.Put(k, nil)
+		}
+	}
+	for _, k := range newo.Keys() {
+		nv, _ := newo.Get(k)
+		ov, exists := oldo.Get(k)
+		if exists && ValuesEqual(ov, nv) {
+			continue
+		}
+		no, newIsObject := ObjectValue(nv)
+		oo, oldIsObject := ObjectValue(ov)
+		if exists && newIsObject && oldIsObject {
+			res.Put(k, CreateMergePatch(oo, no))
+		} else {
+			res.Put(k, DeepCopy(nv))
+		}
+	}
+	return res
+}
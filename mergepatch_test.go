@@ -0,0 +1,39 @@
+package jsonlight
+
+import "testing"
+
+func TestMergePatch(t *testing.T) {
+	// examples from RFC 7386 appendix
+	cases := [][3]string{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, c := range cases {
+		target := NewObjectOrDie(c[0])
+		if err := MergePatch(target, NewObjectOrDie(c[1])); err != nil {
+			t.Fatal(err)
+		}
+		if !ValuesEqual(target, NewObjectOrDie(c[2])) {
+			t.Errorf("%s + %s: expected %s, got %s", c[0], c[1], c[2], target.ToString())
+		}
+	}
+
+	oldo := NewObjectOrDie(`{"a":{"b":1,"c":2},"d":[1],"e":"x"}`)
+	newo, _ := NewOrderedObjectFromString(`{"a":{"b":1,"c":3},"d":[1,2],"f":true}`)
+	patch := CreateMergePatch(oldo, newo)
+	if s := patch.ToString(); s != `{"e":null,"a":{"c":3},"d":[1,2],"f":true}` {
+		t.Errorf("unexpected merge patch %s", s)
+	}
+	MergePatch(oldo, patch)
+	if !ValuesEqual(oldo, newo) {
+		t.Errorf("merge patch %s produced %s", patch.ToString(), oldo.ToString())
+	}
+}
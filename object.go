@@ -1,11 +1,15 @@
 package jsonlight
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -38,6 +42,9 @@ func NewObject(string_or_bytes_or_map_or_struct ...interface{}) (IObject, error)
 	paramsLen := len(string_or_bytes_or_map_or_struct)
 	if paramsLen > 0 {
 		p := string_or_bytes_or_map_or_struct[0]
+		if r, ok := p.(io.Reader); ok {
+			return NewObjectFromReader(r)
+		}
 		v := reflect.ValueOf(p)
 		if v.Kind() == reflect.Ptr {
 			if v.IsNil() {
@@ -89,15 +96,39 @@ func NewObjectFromMap(mapp ...map[string]interface{}) IObject {
 
 func NewObjectFromFile(url string, timeout time.Duration) (IObject, error, int) {
 
-	content, err, code := GetByteContents(url, timeout)
+	r, err, code := OpenContents(url, timeout)
 	if err != nil {
 		return nil, err, code
 	}
+	defer r.Close()
 
-	o, err := NewObjectFromBytes(content)
+	o, err := NewObjectFromReader(r)
 	return o, err, code
 }
 
+// decodes object directly from reader, e.g. http.Request.Body.
+// optional maxSize limits amount of bytes read, TooLargeError is returned when exceeded
+func NewObjectFromReader(r io.Reader, maxSize ...int64) (IObject, error) {
+	return NewObjectFromReaderWithOptions(r, optionsWithMaxSize(maxSize...))
+}
+
+func NewObjectFromBytesWithOptions(data []byte, opts ParseOptions) (IObject, error) {
+	return NewObjectFromReaderWithOptions(bytes.NewReader(data), opts)
+}
+
+func NewObjectFromReaderWithOptions(r io.Reader, opts ParseOptions) (IObject, error) {
+	res, err := decodeReader(r, opts)
+	if err != nil {
+		return nil, err
+	}
+	o, ok := ObjectValue(res)
+	if !ok {
+		return nil, typeMismatch(nil, "object", res)
+	}
+
+	return o, nil
+}
+
 func NewObjectFromString(str string) (IObject, error) {
 	return NewObjectFromBytes([]byte(str))
 }
@@ -126,7 +157,7 @@ func NewObjectFromBytes(bytes []byte) (IObject, error) {
 	}
 	res2, ok := res.(map[string]interface{})
 	if !ok {
-		return nil, TypeConvertError{}
+		return nil, typeMismatch(nil, "object", res)
 	}
 
 	return NewObject(res2)
@@ -224,10 +255,14 @@ func (this *JSONObject) Increment(key string) (int64, error) {
 	}
 	intval, ok := IntValue(v)
 	if !ok {
-		return 0, errors.New("Object.Increment: non-int value already exists under key")
+		return 0, typeMismatch(key, "integer", v)
 	}
 	intval++
-	m[key] = intval
+	if _, isNumber := v.(json.Number); isNumber {
+		m[key] = json.Number(strconv.FormatInt(intval, 10))
+	} else {
+		m[key] = intval
+	}
 	return intval, nil
 }
 
@@ -241,40 +276,20 @@ func (this *JSONObject) Remove(key string) interface{} {
 	return nil
 }
 
+// detached array becomes view of the new slot,
+// array which is attached somewhere else stays there and its copy is stored
 func (this *JSONObject) putArray(key string, a *JSONArray) (interface{}, error) { // XJSON
 	thismap := this.ToMap()
-	var prev interface{}
-	prevexists := false
-
-	a.DetachFromParent()
-	slice, ok := a.ToSlice()
-	if !ok {
-		return nil, errors.New("Array.Put: attempt to put expired array")
-	}
-	prev, prevexists = thismap[key]
-	thismap[key] = slice
-
-	a.data = nil
-	a.a = nil
-	a.akey = -1
-	a.m = *this
-	a.mkey = key
-	// ensure that original array is updated
-	// this will not help to avoid some sad expiration issues
-	// but at least will make this module more secure...
-	if a.originalptr != a {
-		b := a.originalptr
-		b.data = nil
-		b.a = nil
-		b.akey = -1
-		b.m = *this
-		b.mkey = key
-	}
-
-	if prevexists {
+	prev := thismap[key]
+	if a.attached() {
+		thismap[key] = DeepCopy(a.resolve())
 		return prev, nil
 	}
-	return nil, nil
+
+	thismap[key] = a.resolve()
+	a.ptr = nil
+	a.m, a.mkey = thismap, key
+	return prev, nil
 }
 
 func (this *JSONObject) PutAll(o IObject) error {
@@ -297,16 +312,31 @@ func (this *JSONObject) Put(key string, v interface{}) (interface{}, error) { //
 
 	switch vv := v.(type) {
 	default:
-		return nil, errors.New(fmt.Sprintf("Object.Put: unexpected type %T", vv))
-	case JSONObject, []interface{}, int, bool, float32, float64, int8, int16, int32, int64, uint8, uint16, uint32, uint64, string, map[string]interface{}:
+		return nil, PutError{Key: key, ValueType: fmt.Sprintf("%T", vv), Reason: "unsupported value type"}
+	case nil, JSONObject, []interface{}, int, bool, float32, float64, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, string, map[string]interface{}, json.Number:
 		prev, prevexists = thismap[key]
 		thismap[key] = v
+	case *big.Int:
+		if vv == nil {
+			return nil, PutError{Key: key, ValueType: "*big.Int", Reason: "nil pointer"}
+		}
+		return this.Put(key, json.Number(vv.String()))
 	case *JSONArray:
-		return this.putArray(key, v.(*JSONArray)) //this.Put(key, *(v.(*JSONArray)))
+		if vv == nil {
+			return nil, PutError{Key: key, ValueType: "*jsonlight.JSONArray", Reason: "nil pointer"}
+		}
+		return this.putArray(key, vv)
 	case *JSONObject:
 		return this.Put(key, *(v.(*JSONObject)))
+	case *OrderedObject:
+		if vv == nil {
+			return nil, PutError{Key: key, ValueType: "*jsonlight.OrderedObject", Reason: "nil pointer"}
+		}
+		prev, prevexists = thismap[key]
+		thismap[key] = v
 	case JSONArray:
-		return this.putArray(key, v.(JSONArray).originalptr)
+		// copy of the struct cannot be a view, so only contents are stored
+		return this.Put(key, DeepCopy(vv.resolve()))
 	}
 
 	if prevexists {
@@ -315,12 +345,33 @@ func (this *JSONObject) Put(key string, v interface{}) (interface{}, error) { //
 	return nil, nil
 }
 
+// layout is time.RFC3339Nano by default
+func (this *JSONObject) PutTime(key string, t time.Time, layout ...string) (interface{}, error) {
+	if len(layout) > 0 {
+		return this.Put(key, t.Format(layout[0]))
+	}
+	return this.Put(key, t.Format(time.RFC3339Nano))
+}
+func (this *JSONObject) PutDuration(key string, d time.Duration) (interface{}, error) {
+	return this.Put(key, d.String())
+}
+
+// standard base64
+func (this *JSONObject) PutBytes(key string, b []byte) (interface{}, error) {
+	return this.Put(key, base64.StdEncoding.EncodeToString(b))
+}
+
+// s should be pointer, usually to struct. fields are filled like json.Unmarshal does it
 func (this *JSONObject) FillStruct(s interface{}) error {
-	ba := this.ToByteArray()
-	if ba == nil {
-		return TypeConvertError{}
+	return fillStruct(this.ToMap(), s)
+}
+
+// returns nil or FillErrors with every failed field
+func (this *JSONObject) FillStructWithOptions(s interface{}, opts FillOptions) error {
+	if errs := fillStructWithOptions(this.ToMap(), s, &opts); errs != nil {
+		return errs
 	}
-	return json.Unmarshal(ba, s)
+	return nil
 }
 
 //-------------------------------------------------------
@@ -337,56 +388,56 @@ func (this *JSONObject) GetArray(key string) (IArray, error) { // XJSON
 	m := this.ToMap()
 	v, ok := m[key]
 	if !ok {
-		return nil, NotFoundError{}
+		return nil, notFound(key)
 	}
 	_, arrok := v.([]interface{})
 	if !arrok {
-		return nil, TypeConvertError{}
+		return nil, typeMismatch(key, "array", v)
 	}
-	return &JSONArray{m: m, mkey: key}, nil
+	return &JSONArray{data: v.([]interface{}), m: m, mkey: key}, nil
 }
 
 func (this *JSONObject) GetBoolean(key string) (bool, error) {
 	a, ok := this.Get(key)
 	if !ok {
-		return false, NotFoundError{}
+		return false, notFound(key)
 	}
 	if isNil(&a) {
-		return false, NilConvertError{}
+		return false, nilValue(key, "boolean")
 	}
 	if v, ok := a.(bool); ok {
 		return v, nil
 	}
-	return false, TypeConvertError{}
+	return false, typeMismatch(key, "boolean", a)
 }
 func (this *JSONObject) GetString(key string) (string, error) {
 	a, ok := this.Get(key)
 	if !ok {
-		return "", NotFoundError{}
+		return "", notFound(key)
 	}
 	if isNil(&a) {
-		return "", NilConvertError{}
+		return "", nilValue(key, "string")
 	}
 	if v, ok := a.(string); ok {
 		return v, nil
 	}
-	return "", TypeConvertError{}
+	return "", typeMismatch(key, "string", a)
 }
 func (this *JSONObject) GetDouble(key string) (float64, error) {
 	a, ok := this.Get(key)
 	if !ok {
-		return 0, NotFoundError{}
+		return 0, notFound(key)
 	}
 	if isNil(&a) {
-		return 0, NilConvertError{}
-	}
-	if iv, ok := IntValue(a); ok {
-		return float64(iv), nil
+		return 0, nilValue(key, "number")
 	}
 	if v, ok := FloatValue(a); ok {
 		return v, nil
 	}
-	return 0, TypeConvertError{}
+	if iv, ok := IntValue(a); ok {
+		return float64(iv), nil
+	}
+	return 0, typeMismatch(key, "number", a)
 }
 func (this *JSONObject) GetInt(key string) (int, error) {
 	long, err := this.GetLong(key)
@@ -399,27 +450,100 @@ func (this *JSONObject) GetObject(key string) (IObject, error) {
 	m := this.ToMap()
 	v, ok := m[key]
 	if !ok {
-		return nil, NotFoundError{}
+		return nil, notFound(key)
 	}
-	mm, arrok := v.(map[string]interface{})
-	if !arrok {
-		return nil, TypeConvertError{}
+	o, objok := ObjectValue(v)
+	if !objok {
+		return nil, typeMismatch(key, "object", v)
 	}
 
-	return NewObject(mm)
+	return o, nil
 }
 func (this *JSONObject) GetLong(key string) (int64, error) {
 	a, ok := this.Get(key)
 	if !ok {
-		return 0, NotFoundError{}
+		return 0, notFound(key)
 	}
 	if isNil(&a) {
-		return 0, NilConvertError{}
+		return 0, nilValue(key, "integer")
 	}
 	if iv, ok := IntValue(a); ok {
 		return iv, nil
 	}
-	return 0, TypeConvertError{}
+	return 0, typeMismatch(key, "integer", a)
+}
+
+// fails instead of rounding, use ParseOptions.UseNumber to keep big numbers exact
+func (this *JSONObject) GetBigInt(key string) (*big.Int, error) {
+	a, ok := this.Get(key)
+	if !ok {
+		return nil, notFound(key)
+	}
+	if isNil(&a) {
+		return nil, nilValue(key, "integer")
+	}
+	if v, ok := BigIntValue(a); ok {
+		return v, nil
+	}
+	return nil, typeMismatch(key, "integer", a)
+}
+func (this *JSONObject) GetUint64(key string) (uint64, error) {
+	a, ok := this.Get(key)
+	if !ok {
+		return 0, notFound(key)
+	}
+	if isNil(&a) {
+		return 0, nilValue(key, "integer")
+	}
+	if v, ok := Uint64Value(a); ok {
+		return v, nil
+	}
+	return 0, typeMismatch(key, "integer", a)
+}
+
+// RFC 3339 string, custom layouts or unix seconds/millis number, see TimeValue
+func (this *JSONObject) GetTime(key string, layouts ...string) (time.Time, error) {
+	a, ok := this.Get(key)
+	if !ok {
+		return time.Time{}, notFound(key)
+	}
+	if isNil(&a) {
+		return time.Time{}, nilValue(key, "time")
+	}
+	if v, ok := TimeValue(a, layouts...); ok {
+		return v, nil
+	}
+	return time.Time{}, typeMismatch(key, "time", a)
+}
+
+// "1m30s" or number of seconds
+func (this *JSONObject) GetDuration(key string) (time.Duration, error) {
+	a, ok := this.Get(key)
+	if !ok {
+		return 0, notFound(key)
+	}
+	if isNil(&a) {
+		return 0, nilValue(key, "duration")
+	}
+	if v, ok := DurationValue(a); ok {
+		return v, nil
+	}
+	return 0, typeMismatch(key, "duration", a)
+}
+
+// base64 string, standard or url encoding
+func (this *JSONObject) GetBytes(key string) ([]byte, error) {
+	a, ok := this.Get(key)
+	if !ok {
+		return nil, notFound(key)
+	}
+	if isNil(&a) {
+		return nil, nilValue(key, "base64")
+	}
+	if v, ok := BytesValue(a); ok {
+		return v, nil
+	}
+	return nil, typeMismatch(key, "base64", a)
 }
 
 //---------------------
@@ -444,6 +568,36 @@ func (this *JSONObject) OptBoolean(key string, defaultvalue ...bool) bool {
 	}
 	return v
 }
+func (this *JSONObject) OptTime(key string, defaultvalue ...time.Time) time.Time {
+	v, err := this.GetTime(key)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return time.Time{}
+	}
+	return v
+}
+func (this *JSONObject) OptDuration(key string, defaultvalue ...time.Duration) time.Duration {
+	v, err := this.GetDuration(key)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return 0
+	}
+	return v
+}
+func (this *JSONObject) OptBytes(key string, defaultvalue ...[]byte) []byte {
+	v, err := this.GetBytes(key)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return nil
+	}
+	return v
+}
 func (this *JSONObject) OptString(key string, defaultvalue ...string) string {
 	v, err := this.GetString(key)
 	if err != nil {
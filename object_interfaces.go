@@ -2,12 +2,18 @@ package jsonlight
 
 import (
 	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"iter"
+	"math"
+	"math/big"
 	"net/http"
 	"os"
+	"reflect"
+	"sort"
 	"strings"
 	"time"
 )
@@ -17,16 +23,6 @@ import (
 // no chaining
 // nil cannot be type
 
-type NotFoundError struct{}
-type TypeConvertError struct{}
-type NilConvertError struct{}
-type ArrayExpiredError struct{}
-
-func (a NotFoundError) Error() string     { return "Element not found" }
-func (a TypeConvertError) Error() string  { return "Type convertion error" }
-func (a NilConvertError) Error() string   { return "Nil convertion error" }
-func (a ArrayExpiredError) Error() string { return "Array expired" }
-
 type IBaseObject interface {
 	Length() int
 	ToString(indentFactor ...int) string
@@ -45,6 +41,11 @@ type IReadonlyObject interface {
 	GetObject(key string) (IObject, error)
 	GetLong(key string) (int64, error)
 	GetString(key string) (string, error)
+	GetBigInt(key string) (*big.Int, error)
+	GetUint64(key string) (uint64, error)
+	GetTime(key string, layouts ...string) (time.Time, error)
+	GetDuration(key string) (time.Duration, error)
+	GetBytes(key string) ([]byte, error)
 
 	Has(key string) bool
 
@@ -56,6 +57,9 @@ type IReadonlyObject interface {
 	OptObject(key string, defaultvalue ...IObject) IObject
 	OptLong(key string, defaultvalue ...int64) int64
 	OptString(key string, defaultvalue ...string) string
+	OptTime(key string, defaultvalue ...time.Time) time.Time
+	OptDuration(key string, defaultvalue ...time.Duration) time.Duration
+	OptBytes(key string, defaultvalue ...[]byte) []byte
 
 	ToArray(names ...string) IArray
 	ToMap() map[string]interface{}
@@ -64,6 +68,37 @@ type IReadonlyObject interface {
 	IsNull(key string) bool
 
 	Keys() []string
+
+	// iterators, see iter.go
+	All() iter.Seq2[string, interface{}]
+	SortedAll() iter.Seq2[string, interface{}]
+	Objects() iter.Seq2[string, IObject]
+	Arrays() iter.Seq2[string, IArray]
+	Strings() iter.Seq2[string, string]
+	Walk() iter.Seq2[string, interface{}]
+
+	// JSON Pointer (RFC 6901) access, e.g. "/a/b/0/c"
+	GetPointer(pointer string) (interface{}, bool)
+	HasPointer(pointer string) bool
+	GetBooleanPointer(pointer string) (bool, error)
+	GetDoublePointer(pointer string) (float64, error)
+	GetIntPointer(pointer string) (int, error)
+	GetArrayPointer(pointer string) (IArray, error)
+	GetObjectPointer(pointer string) (IObject, error)
+	GetLongPointer(pointer string) (int64, error)
+	GetStringPointer(pointer string) (string, error)
+	OptBooleanPointer(pointer string, defaultvalue ...bool) bool
+	OptDoublePointer(pointer string, defaultvalue ...float64) float64
+	OptIntPointer(pointer string, defaultvalue ...int) int
+	OptArrayPointer(pointer string, defaultvalue ...IArray) IArray
+	OptObjectPointer(pointer string, defaultvalue ...IObject) IObject
+	OptLongPointer(pointer string, defaultvalue ...int64) int64
+	OptStringPointer(pointer string, defaultvalue ...string) string
+
+	// JSONPath (RFC 9535) query, e.g. "$.items[?@.stock > 0].price"
+	Query(expr string) (IArray, error)
+	// normalized paths of values matched by Query, e.g. "$['items'][0]['price']"
+	QueryPaths(expr string) ([]string, error)
 }
 
 type IObject interface {
@@ -80,7 +115,15 @@ type IObject interface {
 	Remove(key string) interface{}
 	Rename(oldkey string, newkey string) bool
 
+	PutTime(key string, t time.Time, layout ...string) (interface{}, error)
+	PutDuration(key string, d time.Duration) (interface{}, error)
+	PutBytes(key string, b []byte) (interface{}, error)
+
+	PutPointer(pointer string, value interface{}, createMissing ...bool) (interface{}, error)
+	RemovePointer(pointer string) interface{}
+
 	FillStruct(s interface{}) error
+	FillStructWithOptions(s interface{}, opts FillOptions) error
 }
 
 // IObject static
@@ -96,6 +139,11 @@ type IArray interface {
 	GetObject(index int) (IObject, error)
 	GetLong(index int) (int64, error)
 	GetString(index int) (string, error)
+	GetBigInt(index int) (*big.Int, error)
+	GetUint64(index int) (uint64, error)
+	GetTime(index int, layouts ...string) (time.Time, error)
+	GetDuration(index int) (time.Duration, error)
+	GetBytes(index int) ([]byte, error)
 
 	Join(separator string) string
 	IsNull(index int) bool
@@ -108,13 +156,75 @@ type IArray interface {
 	OptObject(index int, defaultvalue ...IObject) IObject
 	OptLong(index int, defaultvalue ...int64) int64
 	OptString(index int, defaultvalue ...string) string
+	OptTime(index int, defaultvalue ...time.Time) time.Time
+	OptDuration(index int, defaultvalue ...time.Duration) time.Duration
+	OptBytes(index int, defaultvalue ...[]byte) []byte
 
 	Put(index int, value interface{}) (interface{}, error)
+	PutTime(index int, t time.Time, layout ...string) (interface{}, error)
+	PutDuration(index int, d time.Duration) (interface{}, error)
+	PutBytes(index int, b []byte) (interface{}, error)
 	Append(values ...interface{}) IArray
 	Remove(index int) interface{}
+	Insert(index int, values ...interface{}) error
+	Splice(index int, deleteCount int, values ...interface{}) ([]interface{}, error)
+	Slice(from, to int) IArray
+	IndexOf(value interface{}) int
+	LastIndexOf(value interface{}) int
+	Contains(value interface{}) bool
+	Reverse() IArray
+	Swap(i, j int) error
+	Clear() IArray
+	Concat(others ...IArray) IArray
+
+	// objects and arrays are passed to callbacks as IObject and IArray
+	ForEach(fn func(index int, value interface{}))
+	Map(fn func(index int, value interface{}) interface{}) (IArray, error)
+	Filter(fn func(index int, value interface{}) bool) IArray
+	Find(fn func(index int, value interface{}) bool) (interface{}, bool)
+	FindIndex(fn func(index int, value interface{}) bool) int
+	Any(fn func(index int, value interface{}) bool) bool
+	Every(fn func(index int, value interface{}) bool) bool
+	Reduce(fn func(acc interface{}, index int, value interface{}) interface{}, initial interface{}) interface{}
+	GroupBy(keyFn func(index int, value interface{}) string) IObject
+	SortBy(key_or_comparator interface{}) error
+
+	// iterators, see iter.go
+	All() iter.Seq2[int, interface{}]
+	Objects() iter.Seq2[int, IObject]
+	Arrays() iter.Seq2[int, IArray]
+	Strings() iter.Seq2[int, string]
+	Walk() iter.Seq2[string, interface{}]
 
 	ToSlice() ([]interface{}, bool)
 	ToSliceOrDie() []interface{}
+	// deep copy which is not attached to any parent
+	Copy() IArray
+
+	// JSON Pointer (RFC 6901) access, e.g. "/0/a/b"
+	GetPointer(pointer string) (interface{}, bool)
+	HasPointer(pointer string) bool
+	GetBooleanPointer(pointer string) (bool, error)
+	GetDoublePointer(pointer string) (float64, error)
+	GetIntPointer(pointer string) (int, error)
+	GetArrayPointer(pointer string) (IArray, error)
+	GetObjectPointer(pointer string) (IObject, error)
+	GetLongPointer(pointer string) (int64, error)
+	GetStringPointer(pointer string) (string, error)
+	OptBooleanPointer(pointer string, defaultvalue ...bool) bool
+	OptDoublePointer(pointer string, defaultvalue ...float64) float64
+	OptIntPointer(pointer string, defaultvalue ...int) int
+	OptArrayPointer(pointer string, defaultvalue ...IArray) IArray
+	OptObjectPointer(pointer string, defaultvalue ...IObject) IObject
+	OptLongPointer(pointer string, defaultvalue ...int64) int64
+	OptStringPointer(pointer string, defaultvalue ...string) string
+
+	PutPointer(pointer string, value interface{}, createMissing ...bool) (interface{}, error)
+	RemovePointer(pointer string) interface{}
+
+	// JSONPath (RFC 9535) query, e.g. "$[?@.stock > 0].price"
+	Query(expr string) (IArray, error)
+	QueryPaths(expr string) ([]string, error)
 }
 
 //-------------------------------------
@@ -129,6 +239,69 @@ func isNil(a *interface{}) bool {
 	return *a == nil
 }
 
+// wraps object-like value into IObject without copying
+func ObjectValue(a interface{}) (IObject, bool) {
+	switch o := a.(type) {
+	case map[string]interface{}:
+		x := JSONObject(o)
+		return &x, true
+	case JSONObject:
+		return &o, true
+	case *JSONObject:
+		return o, o != nil
+	case *OrderedObject:
+		return o, o != nil
+	case IObject:
+		return o, o != nil
+	}
+	return nil, false
+}
+
+func ReadonlyObjectValue(a interface{}) (IReadonlyObject, bool) {
+	if o, ok := ObjectValue(a); ok {
+		return o, true
+	}
+	o, ok := a.(IReadonlyObject)
+	return o, ok && o != nil
+}
+
+// keys in document order for ordered objects, sorted otherwise,
+// so that results built by walking objects are stable
+func stableKeys(o IReadonlyObject) []string {
+	keys := o.Keys()
+	if _, ordered := o.(*OrderedObject); !ordered {
+		sort.Strings(keys)
+	}
+	return keys
+}
+
+// underlying slice of array-like value
+func SliceValue(a interface{}) ([]interface{}, bool) {
+	switch s := a.(type) {
+	case []interface{}:
+		return s, true
+	case *JSONArray:
+		if s == nil {
+			return nil, false
+		}
+		return s.ToSlice()
+	case JSONArray:
+		return s.ToSlice()
+	}
+	return nil, false
+}
+
+// any numeric value as float64
+func NumberValue(a interface{}) (float64, bool) {
+	if v, ok := FloatValue(a); ok {
+		return v, true
+	}
+	if iv, ok := IntValue(a); ok {
+		return float64(iv), true
+	}
+	return 0, false
+}
+
 func IntValue(a interface{}) (int64, bool) {
 	if isNil(&a) {
 		return 0, false
@@ -158,6 +331,15 @@ func IntValue(a interface{}) (int64, bool) {
 		return int64(n), true
 	case float32:
 		return int64(n), true
+	case json.Number:
+		if iv, err := n.Int64(); err == nil {
+			return iv, true
+		}
+		f, err := n.Float64()
+		if err != nil || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(f), true
 	default:
 		return 0, false
 	}
@@ -172,12 +354,120 @@ func FloatValue(a interface{}) (float64, bool) {
 		return float64(n), true
 	case float64:
 		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
 	default:
 		return 0, false
 	}
 }
 
-// sllllllloooooowwwww
+// exact integer value, fails for fractional numbers instead of rounding
+func BigIntValue(a interface{}) (*big.Int, bool) {
+	if isNil(&a) {
+		return nil, false
+	}
+	switch n := a.(type) {
+	case *big.Int:
+		return new(big.Int).Set(n), true
+	case json.Number:
+		if b, ok := new(big.Int).SetString(string(n), 10); ok {
+			return b, true
+		}
+		// things like 1e3 or 10.0
+		r, ok := new(big.Rat).SetString(string(n))
+		if !ok || !r.IsInt() {
+			return nil, false
+		}
+		return new(big.Int).Set(r.Num()), true
+	case float32:
+		return BigIntValue(float64(n))
+	case float64:
+		if math.IsInf(n, 0) || math.IsNaN(n) || n != math.Trunc(n) {
+			return nil, false
+		}
+		b, _ := big.NewFloat(n).Int(nil)
+		return b, true
+	case uint:
+		return new(big.Int).SetUint64(uint64(n)), true
+	case uint64:
+		return new(big.Int).SetUint64(n), true
+	}
+	if iv, ok := IntValue(a); ok {
+		return big.NewInt(iv), true
+	}
+	return nil, false
+}
+
+func Uint64Value(a interface{}) (uint64, bool) {
+	b, ok := BigIntValue(a)
+	if !ok || !b.IsUint64() {
+		return 0, false
+	}
+	return b.Uint64(), true
+}
+
+// time.Time, RFC 3339 string or one of custom layouts.
+// numbers are unix time: seconds, or milliseconds if they are too big for seconds
+func TimeValue(a interface{}, layouts ...string) (time.Time, bool) {
+	switch v := a.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			return t, true
+		}
+		for _, layout := range layouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, true
+			}
+		}
+		return time.Time{}, false
+	}
+	f, ok := NumberValue(a)
+	if !ok {
+		return time.Time{}, false
+	}
+	// 1e11 seconds is year 5138, so bigger numbers are surely millis
+	if math.Abs(f) >= 1e11 {
+		return time.UnixMilli(int64(f)), true
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)), true
+}
+
+// time.Duration, string like "1m30s" or number of seconds
+func DurationValue(a interface{}) (time.Duration, bool) {
+	switch v := a.(type) {
+	case time.Duration:
+		return v, true
+	case string:
+		d, err := time.ParseDuration(v)
+		return d, err == nil
+	}
+	f, ok := NumberValue(a)
+	if !ok || math.Abs(f) > math.MaxInt64/float64(time.Second) {
+		return 0, false
+	}
+	return time.Duration(f * float64(time.Second)), true
+}
+
+// []byte or base64 string, padded or not, standard or url-safe
+func BytesValue(a interface{}) ([]byte, bool) {
+	switch v := a.(type) {
+	case []byte:
+		return v, true
+	case string:
+		for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+			if b, err := enc.DecodeString(v); err == nil {
+				return b, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// copies matching fields through intermediate map
 func CopyObject(from interface{}, to interface{}) error {
 	mmap, err := StructToMap(from)
 	if err != nil {
@@ -194,17 +484,14 @@ func StructToMapOrDie(a interface{}) IObject {
 	return res
 }
 
-// encode to json -> decode from json.
-// SLOOOOW
+// a should be struct or map, or pointer to them.
+// numbers become int64, uint64 or float64, everything else is like in json.Marshal
 func StructToMap(a interface{}) (IObject, error) {
-	b, err := json.Marshal(a)
+	v, err := encodeValue(reflect.ValueOf(a))
 	if err != nil {
-		return nil, TypeConvertError{}
+		return nil, err
 	}
-	var f interface{}
-	err = json.Unmarshal(b, &f)
-	if res, ok := f.(map[string]interface{}); ok {
-
+	if res, ok := v.(map[string]interface{}); ok {
 		return NewObject(res)
 	}
 	return nil, TypeConvertError{}
@@ -223,6 +510,22 @@ func Dump2(a interface{}) string {
 //------------------------------
 
 func GetByteContents(url string, timeout time.Duration) ([]byte, error, int) {
+	r, err, code := OpenContents(url, timeout)
+	if err != nil {
+		return nil, err, code
+	}
+	defer r.Close()
+
+	x, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err, code
+	}
+	return x, nil, code
+}
+
+// same as GetByteContents, but leaves reading to the caller.
+// caller must close the returned reader
+func OpenContents(url string, timeout time.Duration) (io.ReadCloser, error, int) {
 	if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
 		tr := &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
@@ -232,21 +535,16 @@ func GetByteContents(url string, timeout time.Duration) ([]byte, error, int) {
 			Transport: tr,
 		}
 		r, err := client.Get(url)
-		defer func() {
+		if err != nil {
 			if r != nil && r.Body != nil {
 				r.Body.Close()
 			}
-		}()
-
-		if err != nil {
 			return nil, err, 0
 		}
 
-		x, err := ioutil.ReadAll(r.Body)
-
-		return x, nil, r.StatusCode
+		return r.Body, nil, r.StatusCode
 	} else {
-		bytes, err := ioutil.ReadFile(url)
+		f, err := os.Open(url)
 		if err != nil {
 			switch {
 			case os.IsNotExist(err):
@@ -257,6 +555,6 @@ func GetByteContents(url string, timeout time.Duration) ([]byte, error, int) {
 				return nil, err, 500
 			}
 		}
-		return bytes, nil, 200
+		return f, nil, 200
 	}
 }
@@ -20,6 +20,7 @@ func TestSomething(t *testing.T) {
 	m := StructToMapOrDie(x).ToMap()
 	m["umm"] = 10
 	fmt.Printf("%+v\n", m)
-	NewObject(m).FillStruct(x)
+	o2, _ := NewObject(m)
+	o2.FillStruct(x)
 	fmt.Printf("%+v\n", x)
 }
@@ -0,0 +1,225 @@
+package jsonlight
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// same as JSONObject, but remembers insertion (or parse) order of keys,
+// so Keys(), ToString() and ToByteArray() produce keys in that order.
+// nested objects of parsed documents are *OrderedObject too.
+// underlying map is still available via ToMap(), keys added or removed
+// directly in that map are picked up lazily
+type OrderedObject struct {
+	JSONObject
+	keys []string
+}
+
+func NewOrderedObject() IObject {
+	return &OrderedObject{JSONObject: JSONObject{}}
+}
+
+func NewOrderedObjectFromString(str string) (IObject, error) {
+	return NewOrderedObjectFromBytes([]byte(str))
+}
+
+func NewOrderedObjectFromBytes(data []byte) (IObject, error) {
+	return NewObjectFromBytesWithOptions(data, ParseOptions{Ordered: true})
+}
+
+func NewOrderedObjectFromReader(r io.Reader, maxSize ...int64) (IObject, error) {
+	opts := optionsWithMaxSize(maxSize...)
+	opts.Ordered = true
+	return NewObjectFromReaderWithOptions(r, opts)
+}
+
+func NewOrderedObjectFromFile(url string, timeout time.Duration) (IObject, error, int) {
+	r, err, code := OpenContents(url, timeout)
+	if err != nil {
+		return nil, err, code
+	}
+	defer r.Close()
+
+	o, err := NewOrderedObjectFromReader(r)
+	return o, err, code
+}
+
+//-----------------------------------------
+
+func (this *OrderedObject) ToReadonlyObject() IReadonlyObject {
+	return IReadonlyObject(this)
+}
+
+// keys without ones that are not in map anymore, plus keys that appeared in map directly.
+// result is a new slice, so reading does not change the object
+func (this *OrderedObject) syncedKeys() []string {
+	seen := make(map[string]bool, len(this.JSONObject))
+	res := make([]string, 0, len(this.JSONObject))
+	for _, k := range this.keys {
+		if _, ok := this.JSONObject[k]; ok && !seen[k] {
+			seen[k] = true
+			res = append(res, k)
+		}
+	}
+	if len(res) < len(this.JSONObject) {
+		added := make([]string, 0, len(this.JSONObject)-len(res))
+		for k := range this.JSONObject {
+			if !seen[k] {
+				added = append(added, k)
+			}
+		}
+		sort.Strings(added)
+		res = append(res, added...)
+	}
+	return res
+}
+
+func (this *OrderedObject) remember(key string, existed bool) {
+	if !existed && this.Has(key) {
+		this.keys = append(this.keys, key)
+	}
+}
+
+func (this *OrderedObject) Keys() []string {
+	return this.syncedKeys()
+}
+
+func (this *OrderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range this.Keys() {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := json.Marshal(this.JSONObject[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (this *OrderedObject) ToString(indentFactor ...int) string {
+	return string(this.ToByteArray(indentFactor...))
+}
+func (this *OrderedObject) ToByteArray(indentFactor ...int) []byte {
+	var x []byte
+	if len(indentFactor) > 0 {
+		x, _ = json.MarshalIndent(this, "", strings.Repeat(" ", indentFactor[0]))
+	} else {
+		x, _ = json.Marshal(this)
+	}
+
+	return x
+}
+
+func (this *OrderedObject) Write(writer *io.Writer) {
+	enc := json.NewEncoder(*writer)
+	enc.Encode(this)
+}
+
+//-----------------------------------------
+
+func (this *OrderedObject) Put(key string, v interface{}) (interface{}, error) {
+	if this.JSONObject == nil {
+		this.JSONObject = JSONObject{}
+	}
+	existed := this.Has(key)
+	prev, err := this.JSONObject.Put(key, v)
+	this.remember(key, existed)
+	return prev, err
+}
+
+// same as in JSONObject, but keeps key order
+func (this *OrderedObject) PutTime(key string, t time.Time, layout ...string) (interface{}, error) {
+	if len(layout) > 0 {
+		return this.Put(key, t.Format(layout[0]))
+	}
+	return this.Put(key, t.Format(time.RFC3339Nano))
+}
+func (this *OrderedObject) PutDuration(key string, d time.Duration) (interface{}, error) {
+	return this.Put(key, d.String())
+}
+
+// standard base64
+func (this *OrderedObject) PutBytes(key string, b []byte) (interface{}, error) {
+	return this.Put(key, base64.StdEncoding.EncodeToString(b))
+}
+
+func (this *OrderedObject) PutAll(o IObject) error {
+	if o == nil {
+		return errors.New("PutAll called with nil param")
+	}
+	for _, k := range o.Keys() {
+		v, _ := o.Get(k)
+		if _, e := this.Put(k, v); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+func (this *OrderedObject) Append(key string, value interface{}) (interface{}, error) {
+	existed := this.Has(key)
+	res, err := this.JSONObject.Append(key, value)
+	this.remember(key, existed)
+	return res, err
+}
+
+func (this *OrderedObject) Increment(key string) (int64, error) {
+	if this.JSONObject == nil {
+		this.JSONObject = JSONObject{}
+	}
+	existed := this.Has(key)
+	res, err := this.JSONObject.Increment(key)
+	this.remember(key, existed)
+	return res, err
+}
+
+func (this *OrderedObject) Remove(key string) interface{} {
+	removed := this.JSONObject.Remove(key)
+	this.keys = this.syncedKeys()
+	return removed
+}
+
+// renamed key keeps its position
+func (this *OrderedObject) Rename(oldkey, newkey string) bool {
+	x, ok := this.Get(oldkey)
+	if !ok {
+		return false
+	}
+	if oldkey == newkey {
+		return true
+	}
+	this.keys = this.syncedKeys()
+	delete(this.JSONObject, newkey)
+	delete(this.JSONObject, oldkey)
+	this.JSONObject[newkey] = x
+
+	res := this.keys[:0]
+	for _, k := range this.keys {
+		switch k {
+		case newkey:
+		case oldkey:
+			res = append(res, newkey)
+		default:
+			res = append(res, k)
+		}
+	}
+	this.keys = res
+	return true
+}
@@ -0,0 +1,55 @@
+package jsonlight
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestOrderedObjectRoundTrip(t *testing.T) {
+	doc := `{"z":1,"a":{"y":[1,{"c":2,"b":3}],"x":null},"m":"s"}`
+	o, err := NewOrderedObjectFromString(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := o.ToString(); s != doc {
+		t.Errorf("order not preserved:\n%s\n%s", doc, s)
+	}
+
+	o.Put("b", true)
+	o.Remove("z")
+	o.Rename("a", "aa")
+	o.OptObject("aa").Put("w", 1)
+	expected := `{"aa":{"y":[1,{"c":2,"b":3}],"x":null,"w":1},"m":"s","b":true}`
+	if s := o.ToString(); s != expected {
+		t.Errorf("unexpected result:\n%s\n%s", expected, s)
+	}
+
+	// arrays are handled by the underlying map
+	o.OptObject("aa").OptArray("y").Append("tail")
+	if s := o.OptObject("aa").OptArray("y").OptString(2); s != "tail" {
+		t.Errorf("append to nested array failed: %s", o.ToString())
+	}
+}
+
+func TestOrderedObjectConcurrentKeys(t *testing.T) {
+	v, _ := NewOrderedObjectFromString(`{"b":1,"a":2}`)
+	o := v.(*OrderedObject)
+	// written behind the order's back, so reads have something to sync
+	o.JSONObject["c"] = 3
+	delete(o.JSONObject, "b")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if k := o.Keys(); len(k) != 2 || k[0] != "a" || k[1] != "c" {
+					t.Errorf("unexpected keys %v", k)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
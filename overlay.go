@@ -0,0 +1,231 @@
+package jsonlight
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// overriding config values from environment variables and command line flags.
+// names are matched against existing keys case-insensitively, ignoring "_" and "-",
+// so APP_DB__HOST or APP_DB__HOST_NAME can both find "db" -> "hostName".
+// values are converted to the type of existing value: "8080" stays a string
+// if existing value is a string and becomes a number if it is a number.
+// for new or null values JSON is tried first, then plain string
+
+type OverlayOptions struct {
+	// only variables with this prefix are used, prefix itself is cut off, e.g. "APP_"
+	Prefix string
+	// separates nesting levels in variable names, "__" by default
+	Separator string
+	// "KEY=value" pairs to use instead of os.Environ()
+	Environ []string
+	// create keys which do not exist yet, otherwise such variables and flags are ignored
+	AllowNew bool
+	// only make report, object is not changed
+	DryRun bool
+}
+
+// single override, Err is set if value could not be converted and was not applied
+type OverlayChange struct {
+	// e.g. "env APP_DB__HOST" or "flag db.host"
+	Source  string
+	Pointer string
+	Old     interface{}
+	New     interface{}
+	Existed bool
+	Err     error
+}
+
+type OverlayReport []OverlayChange
+
+func (this OverlayReport) String() string {
+	var buf strings.Builder
+	for _, c := range this {
+		switch {
+		case c.Err != nil:
+			fmt.Fprintf(&buf, "%s: %v (%s)\n", c.Pointer, c.Err, c.Source)
+		case c.Existed:
+			fmt.Fprintf(&buf, "%s = %s, was %s (%s)\n", c.Pointer, jsonString(c.New), jsonString(c.Old), c.Source)
+		default:
+			fmt.Fprintf(&buf, "%s = %s, new (%s)\n", c.Pointer, jsonString(c.New), c.Source)
+		}
+	}
+	return buf.String()
+}
+
+// all conversion errors joined, nil if every change is fine
+func (this OverlayReport) Err() error {
+	var errs []error
+	for _, c := range this {
+		if c.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.Source, c.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applies environment variables to o, e.g. APP_DB__PORT=5433 with prefix "APP_" sets /db/port.
+// variables are applied in sorted order, error is the same as report.Err()
+func OverlayEnv(o IObject, opts OverlayOptions) (OverlayReport, error) {
+	if o == nil {
+		return nil, errors.New("OverlayEnv called with nil param")
+	}
+	sep := opts.Separator
+	if sep == "" {
+		sep = "__"
+	}
+	environ := opts.Environ
+	if environ == nil {
+		environ = os.Environ()
+	}
+	environ = append([]string(nil), environ...)
+	sort.Strings(environ)
+
+	var report OverlayReport
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, opts.Prefix) || name == opts.Prefix {
+			continue
+		}
+		segments := strings.Split(strings.ToLower(name[len(opts.Prefix):]), sep)
+		if c, ok := overlay(o, segments, value, "env "+name, opts); ok {
+			report = append(report, c)
+		}
+	}
+	return report, report.Err()
+}
+
+// applies flags which were set on command line, flag "db.port" sets /db/port
+func OverlayFlags(o IObject, fs *flag.FlagSet, opts OverlayOptions) (OverlayReport, error) {
+	if o == nil || fs == nil {
+		return nil, errors.New("OverlayFlags called with nil param")
+	}
+	var report OverlayReport
+	fs.Visit(func(f *flag.Flag) {
+		if c, ok := overlay(o, strings.Split(f.Name, "."), f.Value.String(), "flag "+f.Name, opts); ok {
+			report = append(report, c)
+		}
+	})
+	return report, report.Err()
+}
+
+// false if there is no matching key and new keys are not allowed
+func overlay(o IObject, segments []string, raw string, source string, opts OverlayOptions) (OverlayChange, bool) {
+	pointer, ok := overlayPointer(o, segments, opts.AllowNew)
+	if !ok {
+		return OverlayChange{}, false
+	}
+	c := OverlayChange{Source: source, Pointer: pointer}
+	c.Old, c.Existed = o.GetPointer(pointer)
+	c.New, c.Err = overlayValue(raw, c.Old, c.Existed)
+	if c.Err == nil && !opts.DryRun {
+		_, c.Err = o.PutPointer(pointer, c.New, true)
+	}
+	return c, true
+}
+
+// JSON pointer which segments refer to
+func overlayPointer(o IObject, segments []string, allowNew bool) (string, bool) {
+	var cur interface{} = o
+	tokens := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		if seg == "" {
+			return "", false
+		}
+		if co, ok := ReadonlyObjectValue(cur); ok {
+			k, found := matchKey(co, seg)
+			if !found && !allowNew {
+				return "", false
+			}
+			cur, _ = co.Get(k)
+			tokens = append(tokens, k)
+			continue
+		}
+		if s, ok := SliceValue(cur); ok {
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(s) {
+				return "", false
+			}
+			cur = s[i]
+			tokens = append(tokens, seg)
+			continue
+		}
+		if !isNil(&cur) || !allowNew {
+			// cannot go inside of scalar value
+			return "", false
+		}
+		tokens = append(tokens, seg)
+	}
+	return MakePointer(tokens...), len(tokens) > 0
+}
+
+// existing key for name, name itself if there is none
+func matchKey(o IReadonlyObject, name string) (string, bool) {
+	if o.Has(name) {
+		return name, true
+	}
+	keys := stableKeys(o)
+	for _, k := range keys {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	squash := strings.NewReplacer("_", "", "-", "")
+	for _, k := range keys {
+		if strings.EqualFold(squash.Replace(k), squash.Replace(name)) {
+			return k, true
+		}
+	}
+	return name, false
+}
+
+func overlayValue(raw string, old interface{}, existed bool) (interface{}, error) {
+	kind := kindOf(old)
+	if !existed || kind == "null" || kind == "unknown" {
+		var v interface{}
+		if json.Unmarshal([]byte(raw), &v) == nil {
+			return v, nil
+		}
+		return raw, nil
+	}
+
+	switch kind {
+	case "string":
+		return raw, nil
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b, nil
+		}
+	case "number":
+		// only JSON syntax, so things like NaN, Inf or 0x10 are not taken
+		var v interface{}
+		d := json.NewDecoder(strings.NewReader(raw))
+		d.UseNumber()
+		if d.Decode(&v) == nil && !d.More() {
+			if n, ok := v.(json.Number); ok {
+				if i, err := n.Int64(); err == nil {
+					return i, nil
+				}
+				// integers too big for int64 are kept exact, like with ParseOptions.UseNumber
+				if !strings.ContainsAny(string(n), ".eE") {
+					return n, nil
+				}
+				if f, err := n.Float64(); err == nil {
+					return f, nil
+				}
+			}
+		}
+	case "object", "array":
+		var v interface{}
+		if json.Unmarshal([]byte(raw), &v) == nil && kindOf(v) == kind {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot use %q as %s", raw, kind)
+}
@@ -0,0 +1,122 @@
+package jsonlight
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+const overlayConfig = `{"db":{"hostName":"localhost","port":5432,"ssl":false},"servers":[{"addr":"a"}],"tags":["x"],"extra":null,"name":"app"}`
+
+func TestOverlayEnv(t *testing.T) {
+	env := []string{
+		"APP_DB__HOST_NAME=db.internal",
+		"APP_DB__PORT=6543",
+		"APP_DB__SSL=true",
+		"APP_SERVERS__0__ADDR=b",
+		"APP_TAGS=[\"y\",\"z\"]",
+		"APP_EXTRA={\"k\":1}",
+		"APP_NAME=123",
+		"APP_UNKNOWN=1",
+		"OTHER_NAME=ignored",
+	}
+	o := NewObjectOrDie(overlayConfig)
+	report, err := OverlayEnv(o, OverlayOptions{Prefix: "APP_", Environ: env})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NewObjectOrDie(`{"db":{"hostName":"db.internal","port":6543,"ssl":true},"servers":[{"addr":"b"}],"tags":["y","z"],"extra":{"k":1},"name":"123"}`)
+	if !ValuesEqual(o, want) {
+		t.Errorf("got %s", o.ToString())
+	}
+	if len(report) != 7 {
+		t.Errorf("report:\n%s", report)
+	}
+	if !strings.Contains(report.String(), `/db/port = 6543, was 5432 (env APP_DB__PORT)`) {
+		t.Errorf("report:\n%s", report)
+	}
+
+	o = NewObjectOrDie(overlayConfig)
+	report, err = OverlayEnv(o, OverlayOptions{
+		Prefix:   "APP_",
+		Environ:  []string{"APP_DB__PORT=abc", "APP_NEW__KEY=5"},
+		AllowNew: true,
+		DryRun:   true,
+	})
+	if err == nil || !strings.Contains(err.Error(), `env APP_DB__PORT: cannot use "abc" as number`) {
+		t.Errorf("error: %v", err)
+	}
+	if len(report) != 2 || report[1].Pointer != "/new/key" || report[1].Existed || report[1].New != 5.0 {
+		t.Errorf("report:\n%s", report)
+	}
+	if !ValuesEqual(o, NewObjectOrDie(overlayConfig)) {
+		t.Errorf("dry run changed object: %s", o.ToString())
+	}
+
+	for _, raw := range []string{"NaN", "Inf", "-infinity", "0x10", "1_000", "+5"} {
+		if _, err := OverlayEnv(o, OverlayOptions{Prefix: "APP_", Environ: []string{"APP_DB__PORT=" + raw}}); err == nil {
+			t.Errorf("%s should not be taken as number", raw)
+		}
+	}
+	if o.OptIntPointer("/db/port") != 5432 {
+		t.Errorf("bad number was applied: %s", o.ToString())
+	}
+	OverlayEnv(o, OverlayOptions{Prefix: "APP_", Environ: []string{"APP_DB__PORT=1.5e3"}})
+	if o.OptIntPointer("/db/port") != 1500 {
+		t.Errorf("float: %s", o.ToString())
+	}
+
+	// too big for int64, but still exact
+	if _, err := OverlayEnv(o, OverlayOptions{Prefix: "APP_", Environ: []string{"APP_DB__PORT=12345678901234567891"}}); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := o.OptObject("db").GetBigInt("port"); err != nil || b.String() != "12345678901234567891" {
+		t.Errorf("big number: %v %v", b, err)
+	}
+	if !strings.Contains(o.ToString(), `"port":12345678901234567891`) {
+		t.Errorf("big number: %s", o.ToString())
+	}
+
+	OverlayEnv(o, OverlayOptions{Prefix: "APP_", Environ: []string{"APP_NEW__KEY=5"}, AllowNew: true})
+	if o.OptIntPointer("/new/key") != 5 {
+		t.Errorf("new key: %s", o.ToString())
+	}
+}
+
+func TestOverlayFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Int("db.port", 1, "")
+	fs.String("db.hostname", "", "")
+	fs.Bool("db.ssl", false, "")
+	fs.String("name", "default", "")
+	if err := fs.Parse([]string{"-db.port=7000", "-db.ssl", "-name=x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	o := NewObjectOrDie(overlayConfig)
+	report, err := OverlayFlags(o, fs, OverlayOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// flags which are not set keep config values
+	want := NewObjectOrDie(`{"db":{"hostName":"localhost","port":7000,"ssl":true},"servers":[{"addr":"a"}],"tags":["x"],"extra":null,"name":"x"}`)
+	if !ValuesEqual(o, want) || len(report) != 3 {
+		t.Errorf("got %s\n%s", o.ToString(), report)
+	}
+
+	fs.Set("db.ssl", "false")
+	fs.Set("db.port", "1")
+	o.Put("db", "scalar")
+	report, err = OverlayFlags(o, fs, OverlayOptions{AllowNew: true})
+	if err != nil || len(report) != 1 {
+		t.Errorf("values inside scalars cannot be set: %v\n%s", err, report)
+	}
+	if _, err := OverlayFlags(nil, fs, OverlayOptions{}); err == nil {
+		t.Error("nil object should fail")
+	}
+
+	o = NewObjectOrDie(`{"db":{"port":"5432"}}`)
+	if _, err := OverlayFlags(o, fs, OverlayOptions{}); err != nil || o.OptStringPointer("/db/port") != "1" {
+		t.Errorf("string should stay string: %s, %v", o.ToString(), err)
+	}
+}
@@ -0,0 +1,251 @@
+package jsonlight
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSON Patch (RFC 6902)
+
+// describes failed patch operation
+type PatchError struct {
+	Index int
+	Op    string
+	Path  string
+	Err   error
+}
+
+func (a PatchError) Error() string {
+	return fmt.Sprintf("JSON patch: operation %d (%s %s) failed: %s", a.Index, a.Op, a.Path, a.Err)
+}
+
+func (a PatchError) Unwrap() error { return a.Err }
+
+// applies operations one by one. patch is atomic:
+// if some operation fails, target is restored to its original state
+func ApplyPatch(target IObject, patch IArray) error {
+	if target == nil || patch == nil {
+		return errors.New("ApplyPatch called with nil param")
+	}
+	snapshot := DeepCopy(target.ToMap()).(map[string]interface{})
+	keys := target.Keys()
+
+	for i := 0; i < patch.Length(); i++ {
+		if err := applyPatchOperation(target, patch, i); err != nil {
+			restoreObject(target, keys, snapshot)
+			return err
+		}
+	}
+	return nil
+}
+
+func restoreObject(target IObject, keys []string, m map[string]interface{}) {
+	for _, k := range target.Keys() {
+		target.Remove(k)
+	}
+	for _, k := range keys {
+		target.Put(k, m[k])
+	}
+}
+
+func applyPatchOperation(target IObject, patch IArray, index int) error {
+	op, err := patch.GetObject(index)
+	if err != nil {
+		return PatchError{Index: index, Err: errors.New("operation should be an object")}
+	}
+	name := op.OptString("op")
+	path, err := op.GetString("path")
+	fail := func(e error) error {
+		return PatchError{Index: index, Op: name, Path: path, Err: e}
+	}
+	if err != nil {
+		return fail(errors.New("path is missing"))
+	}
+	value, hasValue := op.Get("value")
+	if !hasValue && (name == "add" || name == "replace" || name == "test") {
+		return fail(errors.New("value is missing"))
+	}
+	from, err := op.GetString("from")
+	if err != nil && (name == "move" || name == "copy") {
+		return fail(errors.New("from is missing"))
+	}
+
+	switch name {
+	case "add":
+		err = patchAdd(target, path, DeepCopy(value))
+	case "remove":
+		_, err = patchRemove(target, path)
+	case "replace":
+		err = patchReplace(target, path, DeepCopy(value))
+	case "move":
+		if from == path {
+			return nil
+		}
+		if strings.HasPrefix(path, from+"/") {
+			return fail(errors.New("cannot move value into itself"))
+		}
+		var v interface{}
+		if v, err = patchRemove(target, from); err == nil {
+			err = patchAdd(target, path, v)
+		}
+	case "copy":
+		var slot *pointerSlot
+		if slot, err = resolvePointer(target, from, false); err == nil {
+			v, ok := slot.get()
+			if !ok {
+				return fail(NotFoundError{})
+			}
+			err = patchAdd(target, path, DeepCopy(v))
+		}
+	case "test":
+		var slot *pointerSlot
+		if slot, err = resolvePointer(target, path, false); err == nil {
+			v, ok := slot.get()
+			if !ok || !ValuesEqual(v, value) {
+				err = errors.New("test failed")
+			}
+		}
+	default:
+		err = errors.New("unknown operation")
+	}
+	if err != nil {
+		return fail(err)
+	}
+	return nil
+}
+
+// inserts into arrays, replaces object members
+func patchAdd(target IObject, path string, value interface{}) error {
+	slot, err := resolvePointer(target, path, false)
+	if err != nil {
+		return err
+	}
+	switch {
+	case slot.o != nil:
+		_, err = slot.o.Put(slot.key, value)
+		return err
+	case slot.a != nil:
+		return slot.a.Insert(slot.index, value)
+	}
+
+	o, ok := ObjectValue(value)
+	if !ok {
+		return errors.New("document root can be replaced with object only")
+	}
+	restoreObject(target, o.Keys(), o.ToMap())
+	return nil
+}
+
+// unlike remove + add keeps position of object member
+func patchReplace(target IObject, path string, value interface{}) error {
+	slot, err := resolvePointer(target, path, false)
+	if err != nil {
+		return err
+	}
+	if _, ok := slot.get(); !ok || (slot.a != nil && slot.index >= slot.a.Length()) {
+		return NotFoundError{}
+	}
+	if slot.o == nil && slot.a == nil {
+		return patchAdd(target, path, value)
+	}
+	_, err = slot.put(value)
+	return err
+}
+
+func patchRemove(target IObject, path string) (interface{}, error) {
+	slot, err := resolvePointer(target, path, false)
+	if err != nil {
+		return nil, err
+	}
+	if slot.o == nil && slot.a == nil {
+		return nil, errors.New("document root cannot be removed")
+	}
+	v, ok := slot.get()
+	if !ok {
+		return nil, NotFoundError{}
+	}
+	slot.remove()
+	return v, nil
+}
+
+//-------------------------------------
+
+// makes patch which turns old into new.
+// nested objects and arrays are compared recursively,
+// so only changed leaves are mentioned in the patch
+func CreatePatch(oldo IObject, newo IObject) IArray {
+	ops := make([]interface{}, 0)
+	createPatch("", oldo, newo, &ops)
+	return NewArrayFromSlice(ops)
+}
+
+func patchOperation(op string, path string, value ...interface{}) *OrderedObject {
+	o := &OrderedObject{JSONObject: JSONObject{}}
+	o.Put("op", op)
+	o.Put("path", path)
+	if len(value) > 0 {
+		o.Put("value", DeepCopy(value[0]))
+	}
+	return o
+}
+
+func createPatch(path string, a, b interface{}, ops *[]interface{}) {
+	if ValuesEqual(a, b) {
+		return
+	}
+
+	ao, aok := ObjectValue(a)
+	bo, bok := ObjectValue(b)
+	if aok && bok {
+		for _, k := range stableKeys(ao) {
+			if !bo.Has(k) {
+				*ops = append(*ops, patchOperation("remove", path+"/"+EscapePointerToken(k)))
+			}
+		}
+		// stable patch for the same input, document order for ordered objects
+		for _, k := range stableKeys(bo) {
+			bv, _ := bo.Get(k)
+			if av, ok := ao.Get(k); ok {
+				createPatch(path+"/"+EscapePointerToken(k), av, bv, ops)
+			} else {
+				*ops = append(*ops, patchOperation("add", path+"/"+EscapePointerToken(k), bv))
+			}
+		}
+		return
+	}
+
+	as, aok := SliceValue(a)
+	bs, bok := SliceValue(b)
+	if aok && bok {
+		// common head and tail stay untouched
+		prefix := 0
+		for prefix < len(as) && prefix < len(bs) && ValuesEqual(as[prefix], bs[prefix]) {
+			prefix++
+		}
+		suffix := 0
+		for suffix < len(as)-prefix && suffix < len(bs)-prefix && ValuesEqual(as[len(as)-1-suffix], bs[len(bs)-1-suffix]) {
+			suffix++
+		}
+		am := as[prefix : len(as)-suffix]
+		bm := bs[prefix : len(bs)-suffix]
+
+		common := len(am)
+		if len(bm) < common {
+			common = len(bm)
+		}
+		for i := 0; i < common; i++ {
+			createPatch(path+"/"+strconv.Itoa(prefix+i), am[i], bm[i], ops)
+		}
+		for i := len(am) - 1; i >= common; i-- {
+			*ops = append(*ops, patchOperation("remove", path+"/"+strconv.Itoa(prefix+i)))
+		}
+		for i := common; i < len(bm); i++ {
+			*ops = append(*ops, patchOperation("add", path+"/"+strconv.Itoa(prefix+i), bm[i]))
+		}
+		return
+	}
+
+	*ops = append(*ops, patchOperation("replace", path, b))
+}
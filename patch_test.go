@@ -0,0 +1,71 @@
+package jsonlight
+
+import "testing"
+
+func TestApplyPatch(t *testing.T) {
+	o := NewObjectOrDie(`{"a":{"b":[1,2,3]},"c":"x"}`)
+	patch := NewArrayOrDie(`[
+		{"op":"add","path":"/a/b/1","value":10},
+		{"op":"remove","path":"/a/b/0"},
+		{"op":"replace","path":"/c","value":{"d":null}},
+		{"op":"copy","from":"/a/b","path":"/e"},
+		{"op":"move","from":"/c/d","path":"/f"},
+		{"op":"test","path":"/e","value":[10,2,3.0]}
+	]`)
+	if err := ApplyPatch(o, patch); err != nil {
+		t.Fatal(err)
+	}
+	expected := NewObjectOrDie(`{"a":{"b":[10,2,3]},"c":{},"e":[10,2,3],"f":null}`)
+	if !ValuesEqual(o, expected) {
+		t.Errorf("unexpected result %s", o.ToString())
+	}
+
+	before := o.ToString()
+	failing := NewArrayOrDie(`[
+		{"op":"remove","path":"/a"},
+		{"op":"add","path":"/x","value":1},
+		{"op":"test","path":"/x","value":2}
+	]`)
+	err := ApplyPatch(o, failing)
+	if perr, ok := err.(PatchError); !ok || perr.Index != 2 {
+		t.Errorf("expected failure of operation 2, got %v", err)
+	}
+	if after := o.ToString(); after != before {
+		t.Errorf("object not restored:\n%s\n%s", before, after)
+	}
+}
+
+func TestCreatePatch(t *testing.T) {
+	docs := [][2]string{
+		{`{"a":1,"b":{"c":[1,2,3,4]},"d":"x"}`, `{"a":1,"b":{"c":[1,5,4]},"e":[]}`},
+		{`{"list":[1,2,3]}`, `{"list":[0,1,2,3]}`},
+		{`{"list":[{"id":1,"v":"a"},{"id":2}]}`, `{"list":[{"id":1,"v":"b"}]}`},
+	}
+	for _, d := range docs {
+		oldo, newo := NewObjectOrDie(d[0]), NewObjectOrDie(d[1])
+		patch := CreatePatch(oldo, newo)
+		if err := ApplyPatch(oldo, patch); err != nil {
+			t.Fatal(err)
+		}
+		if !ValuesEqual(oldo, newo) {
+			t.Errorf("patch %s produced %s instead of %s", patch.ToString(), oldo.ToString(), d[1])
+		}
+	}
+
+	patch := CreatePatch(NewObjectOrDie(`{"list":[1,2,3]}`), NewObjectOrDie(`{"list":[0,1,2,3]}`))
+	if s := patch.ToString(); s != `[{"op":"add","path":"/list/0","value":0}]` {
+		t.Errorf("patch is not minimal: %s", s)
+	}
+
+	// added keys come sorted, unless the new object keeps its own order
+	expected := `[{"op":"add","path":"/a","value":1},{"op":"add","path":"/b","value":2},{"op":"add","path":"/c","value":3}]`
+	for i := 0; i < 10; i++ {
+		if s := CreatePatch(NewObjectOrDie(`{}`), NewObjectOrDie(`{"c":3,"a":1,"b":2}`)).ToString(); s != expected {
+			t.Fatalf("unexpected patch %s", s)
+		}
+	}
+	ordered, _ := NewOrderedObjectFromString(`{"c":3,"a":1}`)
+	if s := CreatePatch(NewObjectOrDie(`{}`), ordered).ToString(); s != `[{"op":"add","path":"/c","value":3},{"op":"add","path":"/a","value":1}]` {
+		t.Errorf("ordered keys not kept: %s", s)
+	}
+}
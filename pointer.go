@@ -0,0 +1,628 @@
+package jsonlight
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// JSON Pointer (RFC 6901) support: "/a/b/0/c", "~1" stands for "/", "~0" for "~".
+// empty pointer refers to the whole document
+
+// escapes single reference token, e.g. "a/b" -> "a~1b"
+func EscapePointerToken(token string) string {
+	token = strings.Replace(token, "~", "~0", -1)
+	return strings.Replace(token, "/", "~1", -1)
+}
+
+func UnescapePointerToken(token string) (string, error) {
+	for i := 0; i < len(token); i++ {
+		if token[i] == '~' && (i+1 >= len(token) || (token[i+1] != '0' && token[i+1] != '1')) {
+			return "", errors.New("JSON pointer: bad escape sequence in " + token)
+		}
+	}
+	token = strings.Replace(token, "~1", "/", -1)
+	return strings.Replace(token, "~0", "~", -1), nil
+}
+
+func ParsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if pointer[0] != '/' {
+		return nil, errors.New("JSON pointer should start with /: " + pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		u, err := UnescapePointerToken(t)
+		if err != nil {
+			return nil, err
+		}
+		tokens[i] = u
+	}
+	return tokens, nil
+}
+
+func MakePointer(tokens ...string) string {
+	var buf strings.Builder
+	for _, t := range tokens {
+		buf.WriteByte('/')
+		buf.WriteString(EscapePointerToken(t))
+	}
+	return buf.String()
+}
+
+// "-" means index right after the last element, allowed only if allowEnd is set
+func pointerIndex(token string, length int, allowEnd bool) (int, error) {
+	if token == "-" {
+		if allowEnd {
+			return length, nil
+		}
+		return 0, notFound(token)
+	}
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, errors.New("JSON pointer: bad array index " + token)
+	}
+	for _, c := range token {
+		if c < '0' || c > '9' {
+			return 0, errors.New("JSON pointer: bad array index " + token)
+		}
+	}
+	index, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, errors.New("JSON pointer: bad array index " + token)
+	}
+	if index > length || (index == length && !allowEnd) {
+		return 0, notFound(index)
+	}
+	return index, nil
+}
+
+func isPointerIndex(token string) bool {
+	if token == "-" {
+		return true
+	}
+	_, err := pointerIndex(token, int(^uint(0)>>1), false)
+	return err == nil
+}
+
+//-------------------------------------
+
+// parent container and key of the value pointer refers to.
+// if pointer is empty, only root is set
+type pointerSlot struct {
+	o     IObject
+	a     IArray
+	key   string
+	index int
+	root  interface{}
+	// pointer of o, used in errors. arrays know their own location
+	path string
+}
+
+func resolvePointer(root interface{}, pointer string, create bool) (*pointerSlot, error) {
+	tokens, err := ParsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return &pointerSlot{root: root}, nil
+	}
+
+	// objects do not know where they are, so the walk keeps track of it
+	cur, path := root, ""
+	if a, ok := root.(*JSONArray); ok {
+		path = a.pointer()
+	}
+	for i := 0; i < len(tokens)-1; i++ {
+		next, err := pointerStep(cur, tokens[i], create, tokens[i+1])
+		if err != nil {
+			return nil, locateError(err, path)
+		}
+		if _, fromobject := cur.(IObject); fromobject {
+			// fresh view of the object's slot, so it can learn where the object is
+			if a, ok := next.(*JSONArray); ok && a.m != nil {
+				a.base = path
+			}
+		}
+		cur = next
+		path += keyPath(tokens[i])
+	}
+
+	last := tokens[len(tokens)-1]
+	switch c := cur.(type) {
+	case IObject:
+		return &pointerSlot{o: c, key: last, path: path}, nil
+	case IArray:
+		index, err := pointerIndex(last, c.Length(), true)
+		if err != nil {
+			return nil, locateError(err, path)
+		}
+		return &pointerSlot{a: c, index: index}, nil
+	}
+	return nil, typeMismatch(nil, "object or array", cur)
+}
+
+func newPointerContainer(parent interface{}, nexttoken string) interface{} {
+	if isPointerIndex(nexttoken) {
+		return []interface{}{}
+	}
+	if _, ordered := parent.(*OrderedObject); ordered {
+		return NewOrderedObject()
+	}
+	return map[string]interface{}{}
+}
+
+// returns IObject or IArray under token
+func pointerStep(cur interface{}, token string, create bool, nexttoken string) (interface{}, error) {
+	switch c := cur.(type) {
+	case IObject:
+		if o, err := c.GetObject(token); err == nil {
+			return o, nil
+		}
+		if a, err := c.GetArray(token); err == nil {
+			return a, nil
+		}
+		if v, ok := c.Get(token); ok && !isNil(&v) {
+			return nil, typeMismatch(token, "object or array", v)
+		}
+		if !create {
+			return nil, notFound(token)
+		}
+		if _, err := c.Put(token, newPointerContainer(c, nexttoken)); err != nil {
+			return nil, err
+		}
+		return pointerStep(c, token, false, nexttoken)
+	case IArray:
+		index, err := pointerIndex(token, c.Length(), create)
+		if err != nil {
+			return nil, err
+		}
+		if o, err := c.GetObject(index); err == nil {
+			return o, nil
+		}
+		if a, err := c.GetArray(index); err == nil {
+			return a, nil
+		}
+		if v, ok := c.Get(index); ok && !isNil(&v) {
+			return nil, typeMismatch(index, "object or array", v)
+		}
+		if !create {
+			return nil, notFound(index)
+		}
+		container := newPointerContainer(c, nexttoken)
+		if index == c.Length() {
+			c.Append(container)
+		} else if _, err := c.Put(index, container); err != nil {
+			return nil, err
+		}
+		return pointerStep(c, strconv.Itoa(index), false, nexttoken)
+	}
+	return nil, typeMismatch(nil, "object or array", cur)
+}
+
+func (this *pointerSlot) get() (interface{}, bool) {
+	if this.o != nil {
+		return this.o.Get(this.key)
+	}
+	if this.a != nil {
+		return this.a.Get(this.index)
+	}
+	return this.root, true
+}
+
+// puts to the end of array if index is "-" or equal to array length
+func (this *pointerSlot) put(v interface{}) (interface{}, error) {
+	if this.o != nil {
+		return this.o.Put(this.key, v)
+	}
+	if this.a != nil {
+		if this.index == this.a.Length() {
+			this.a.Append(v)
+			return nil, nil
+		}
+		return this.a.Put(this.index, v)
+	}
+	return nil, errors.New("JSON pointer: cannot replace document root")
+}
+
+func (this *pointerSlot) remove() interface{} {
+	if this.o != nil {
+		return this.o.Remove(this.key)
+	}
+	if this.a != nil && this.index < this.a.Length() {
+		return this.a.Remove(this.index)
+	}
+	return nil
+}
+
+func (this *pointerSlot) getBoolean() (bool, error) {
+	if this.o != nil {
+		v, err := this.o.GetBoolean(this.key)
+		return v, locateError(err, this.path)
+	}
+	if this.a != nil {
+		return this.a.GetBoolean(this.index)
+	}
+	return false, typeMismatch(nil, "boolean", this.root)
+}
+func (this *pointerSlot) getDouble() (float64, error) {
+	if this.o != nil {
+		v, err := this.o.GetDouble(this.key)
+		return v, locateError(err, this.path)
+	}
+	if this.a != nil {
+		return this.a.GetDouble(this.index)
+	}
+	return 0, typeMismatch(nil, "number", this.root)
+}
+func (this *pointerSlot) getInt() (int, error) {
+	if this.o != nil {
+		v, err := this.o.GetInt(this.key)
+		return v, locateError(err, this.path)
+	}
+	if this.a != nil {
+		return this.a.GetInt(this.index)
+	}
+	return 0, typeMismatch(nil, "integer", this.root)
+}
+func (this *pointerSlot) getArray() (IArray, error) {
+	if this.o != nil {
+		v, err := this.o.GetArray(this.key)
+		return v, locateError(err, this.path)
+	}
+	if this.a != nil {
+		return this.a.GetArray(this.index)
+	}
+	if a, ok := this.root.(IArray); ok {
+		return a, nil
+	}
+	return nil, typeMismatch(nil, "array", this.root)
+}
+func (this *pointerSlot) getObject() (IObject, error) {
+	if this.o != nil {
+		v, err := this.o.GetObject(this.key)
+		return v, locateError(err, this.path)
+	}
+	if this.a != nil {
+		return this.a.GetObject(this.index)
+	}
+	if o, ok := this.root.(IObject); ok {
+		return o, nil
+	}
+	return nil, typeMismatch(nil, "object", this.root)
+}
+func (this *pointerSlot) getLong() (int64, error) {
+	if this.o != nil {
+		v, err := this.o.GetLong(this.key)
+		return v, locateError(err, this.path)
+	}
+	if this.a != nil {
+		return this.a.GetLong(this.index)
+	}
+	return 0, typeMismatch(nil, "integer", this.root)
+}
+func (this *pointerSlot) getString() (string, error) {
+	if this.o != nil {
+		v, err := this.o.GetString(this.key)
+		return v, locateError(err, this.path)
+	}
+	if this.a != nil {
+		return this.a.GetString(this.index)
+	}
+	return "", typeMismatch(nil, "string", this.root)
+}
+
+//-------------------------------------
+
+func (this *JSONObject) GetPointer(pointer string) (interface{}, bool) {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return nil, false
+	}
+	return slot.get()
+}
+func (this *JSONObject) HasPointer(pointer string) bool {
+	_, ok := this.GetPointer(pointer)
+	return ok
+}
+
+// createMissing makes intermediate objects and arrays (for numeric tokens) if they do not exist
+func (this *JSONObject) PutPointer(pointer string, value interface{}, createMissing ...bool) (interface{}, error) {
+	slot, err := resolvePointer(this, pointer, len(createMissing) > 0 && createMissing[0])
+	if err != nil {
+		return nil, err
+	}
+	return slot.put(value)
+}
+func (this *JSONObject) RemovePointer(pointer string) interface{} {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return nil
+	}
+	return slot.remove()
+}
+func (this *JSONObject) GetBooleanPointer(pointer string) (bool, error) {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return false, err
+	}
+	return slot.getBoolean()
+}
+func (this *JSONObject) GetDoublePointer(pointer string) (float64, error) {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return 0, err
+	}
+	return slot.getDouble()
+}
+func (this *JSONObject) GetIntPointer(pointer string) (int, error) {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return 0, err
+	}
+	return slot.getInt()
+}
+func (this *JSONObject) GetArrayPointer(pointer string) (IArray, error) {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return nil, err
+	}
+	return slot.getArray()
+}
+func (this *JSONObject) GetObjectPointer(pointer string) (IObject, error) {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return nil, err
+	}
+	return slot.getObject()
+}
+func (this *JSONObject) GetLongPointer(pointer string) (int64, error) {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return 0, err
+	}
+	return slot.getLong()
+}
+func (this *JSONObject) GetStringPointer(pointer string) (string, error) {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return "", err
+	}
+	return slot.getString()
+}
+func (this *JSONObject) OptBooleanPointer(pointer string, defaultvalue ...bool) bool {
+	v, err := this.GetBooleanPointer(pointer)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return false
+	}
+	return v
+}
+func (this *JSONObject) OptDoublePointer(pointer string, defaultvalue ...float64) float64 {
+	v, err := this.GetDoublePointer(pointer)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return 0
+	}
+	return v
+}
+func (this *JSONObject) OptIntPointer(pointer string, defaultvalue ...int) int {
+	v, err := this.GetIntPointer(pointer)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return 0
+	}
+	return v
+}
+func (this *JSONObject) OptArrayPointer(pointer string, defaultvalue ...IArray) IArray {
+	v, err := this.GetArrayPointer(pointer)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return nil
+	}
+	return v
+}
+func (this *JSONObject) OptObjectPointer(pointer string, defaultvalue ...IObject) IObject {
+	v, err := this.GetObjectPointer(pointer)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return nil
+	}
+	return v
+}
+func (this *JSONObject) OptLongPointer(pointer string, defaultvalue ...int64) int64 {
+	v, err := this.GetLongPointer(pointer)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return 0
+	}
+	return v
+}
+func (this *JSONObject) OptStringPointer(pointer string, defaultvalue ...string) string {
+	v, err := this.GetStringPointer(pointer)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return ""
+	}
+	return v
+}
+
+// mutating methods are overridden to keep track of key order
+func (this *OrderedObject) PutPointer(pointer string, value interface{}, createMissing ...bool) (interface{}, error) {
+	slot, err := resolvePointer(this, pointer, len(createMissing) > 0 && createMissing[0])
+	if err != nil {
+		return nil, err
+	}
+	return slot.put(value)
+}
+func (this *OrderedObject) RemovePointer(pointer string) interface{} {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return nil
+	}
+	return slot.remove()
+}
+
+//-------------------------------------
+
+func (this *JSONArray) GetPointer(pointer string) (interface{}, bool) {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return nil, false
+	}
+	return slot.get()
+}
+func (this *JSONArray) HasPointer(pointer string) bool {
+	_, ok := this.GetPointer(pointer)
+	return ok
+}
+
+// createMissing makes intermediate objects and arrays (for numeric tokens) if they do not exist
+func (this *JSONArray) PutPointer(pointer string, value interface{}, createMissing ...bool) (interface{}, error) {
+	slot, err := resolvePointer(this, pointer, len(createMissing) > 0 && createMissing[0])
+	if err != nil {
+		return nil, err
+	}
+	return slot.put(value)
+}
+func (this *JSONArray) RemovePointer(pointer string) interface{} {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return nil
+	}
+	return slot.remove()
+}
+func (this *JSONArray) GetBooleanPointer(pointer string) (bool, error) {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return false, err
+	}
+	return slot.getBoolean()
+}
+func (this *JSONArray) GetDoublePointer(pointer string) (float64, error) {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return 0, err
+	}
+	return slot.getDouble()
+}
+func (this *JSONArray) GetIntPointer(pointer string) (int, error) {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return 0, err
+	}
+	return slot.getInt()
+}
+func (this *JSONArray) GetArrayPointer(pointer string) (IArray, error) {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return nil, err
+	}
+	return slot.getArray()
+}
+func (this *JSONArray) GetObjectPointer(pointer string) (IObject, error) {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return nil, err
+	}
+	return slot.getObject()
+}
+func (this *JSONArray) GetLongPointer(pointer string) (int64, error) {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return 0, err
+	}
+	return slot.getLong()
+}
+func (this *JSONArray) GetStringPointer(pointer string) (string, error) {
+	slot, err := resolvePointer(this, pointer, false)
+	if err != nil {
+		return "", err
+	}
+	return slot.getString()
+}
+func (this *JSONArray) OptBooleanPointer(pointer string, defaultvalue ...bool) bool {
+	v, err := this.GetBooleanPointer(pointer)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return false
+	}
+	return v
+}
+func (this *JSONArray) OptDoublePointer(pointer string, defaultvalue ...float64) float64 {
+	v, err := this.GetDoublePointer(pointer)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return 0
+	}
+	return v
+}
+func (this *JSONArray) OptIntPointer(pointer string, defaultvalue ...int) int {
+	v, err := this.GetIntPointer(pointer)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return 0
+	}
+	return v
+}
+func (this *JSONArray) OptArrayPointer(pointer string, defaultvalue ...IArray) IArray {
+	v, err := this.GetArrayPointer(pointer)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return nil
+	}
+	return v
+}
+func (this *JSONArray) OptObjectPointer(pointer string, defaultvalue ...IObject) IObject {
+	v, err := this.GetObjectPointer(pointer)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return nil
+	}
+	return v
+}
+func (this *JSONArray) OptLongPointer(pointer string, defaultvalue ...int64) int64 {
+	v, err := this.GetLongPointer(pointer)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return 0
+	}
+	return v
+}
+func (this *JSONArray) OptStringPointer(pointer string, defaultvalue ...string) string {
+	v, err := this.GetStringPointer(pointer)
+	if err != nil {
+		if len(defaultvalue) > 0 {
+			return defaultvalue[0]
+		}
+		return ""
+	}
+	return v
+}
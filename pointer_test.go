@@ -0,0 +1,51 @@
+package jsonlight
+
+import "testing"
+
+func TestPointer(t *testing.T) {
+	o := NewObjectOrDie(`{"a":{"b":[{"c":"x"},{"c":"y"}]},"m~n":{"k/l":5},"":1}`)
+
+	if s, err := o.GetStringPointer("/a/b/1/c"); err != nil || s != "y" {
+		t.Errorf("unexpected result %q %v", s, err)
+	}
+	if v := o.OptLongPointer("/m~0n/k~1l"); v != 5 {
+		t.Errorf("escaping not handled: %d", v)
+	}
+	if v := o.OptIntPointer("/"); v != 1 {
+		t.Errorf("empty key not handled: %d", v)
+	}
+	if o.HasPointer("/a/b/2") || o.HasPointer("/a/b/01") || o.HasPointer("/a/x/c") {
+		t.Error("unexpected pointer match")
+	}
+	if _, err := o.GetStringPointer("/a/b/0/c/d"); err == nil {
+		t.Error("descending into string should fail")
+	}
+
+	if _, err := o.PutPointer("/a/b/-", "tail"); err != nil {
+		t.Fatal(err)
+	}
+	if s := o.OptStringPointer("/a/b/2"); s != "tail" {
+		t.Errorf("append failed: %s", o.ToString())
+	}
+	if _, err := o.PutPointer("/x/y/0/z", true); err == nil {
+		t.Error("missing intermediates should not be created by default")
+	}
+	if _, err := o.PutPointer("/x/y/0/z", true, true); err != nil {
+		t.Fatal(err)
+	}
+	if !o.OptBooleanPointer("/x/y/0/z") {
+		t.Errorf("intermediates not created: %s", o.ToString())
+	}
+
+	if removed := o.RemovePointer("/a/b/0"); removed == nil {
+		t.Error("nothing removed")
+	}
+	if s := o.OptStringPointer("/a/b/0/c"); s != "y" {
+		t.Errorf("unexpected array after removal: %s", o.ToString())
+	}
+
+	a := NewArrayOrDie(`[{"a":[1,2]}]`)
+	if v := a.OptLongPointer("/0/a/1"); v != 2 {
+		t.Errorf("array pointer failed: %d", v)
+	}
+}
@@ -0,0 +1,131 @@
+package jsonlight
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// reads at most limit bytes, fails with TooLargeError if there is more
+type limitedReader struct {
+	r     io.Reader
+	left  int64
+	limit int64
+}
+
+func (this *limitedReader) Read(p []byte) (int, error) {
+	if this.left <= 0 {
+		var probe [1]byte
+		n, err := this.r.Read(probe[:])
+		if n > 0 {
+			return 0, TooLargeError{Limit: this.limit}
+		}
+		return 0, err
+	}
+	if int64(len(p)) > this.left {
+		p = p[:this.left]
+	}
+	n, err := this.r.Read(p)
+	this.left -= int64(n)
+	return n, err
+}
+
+// parsing options for ...WithOptions constructors
+type ParseOptions struct {
+	// keep numbers as json.Number instead of float64,
+	// so that big integers (ids etc) are not rounded
+	UseNumber bool
+	// max amount of bytes to read, 0 means unlimited
+	MaxSize int64
+	// produce *OrderedObject instead of plain maps, keeping keys in document order
+	Ordered bool
+}
+
+func optionsWithMaxSize(maxSize ...int64) ParseOptions {
+	if len(maxSize) > 0 {
+		return ParseOptions{MaxSize: maxSize[0]}
+	}
+	return ParseOptions{}
+}
+
+// decodes single json value from reader without buffering the whole input first
+func decodeReader(r io.Reader, opts ParseOptions) (interface{}, error) {
+	if r == nil {
+		return nil, errors.New("nil reader")
+	}
+	if opts.MaxSize > 0 {
+		r = &limitedReader{r: r, left: opts.MaxSize, limit: opts.MaxSize}
+	}
+
+	dec := json.NewDecoder(r)
+	if opts.UseNumber {
+		dec.UseNumber()
+	}
+	var res interface{}
+	var err error
+	if opts.Ordered {
+		res, err = decodeOrdered(dec)
+	} else {
+		err = dec.Decode(&res)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		if err != nil {
+			return nil, err
+		}
+		return nil, errors.New("unexpected data after top-level value")
+	}
+	return res, nil
+}
+
+// same as dec.Decode, but builds *OrderedObject for every json object
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	t, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := t.(json.Delim)
+	if !ok {
+		return t, nil
+	}
+
+	switch delim {
+	case '{':
+		o := &OrderedObject{JSONObject: JSONObject{}}
+		for dec.More() {
+			kt, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, _ := kt.(string)
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := o.JSONObject[key]; !exists {
+				o.keys = append(o.keys, key)
+			}
+			o.JSONObject[key] = v
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return o, nil
+	case '[':
+		res := make([]interface{}, 0)
+		for dec.More() {
+			v, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, v)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+	return nil, errors.New("unexpected delimiter " + delim.String())
+}
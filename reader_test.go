@@ -0,0 +1,62 @@
+package jsonlight
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewObjectFromReader(t *testing.T) {
+	doc := `{"a":[1,2,3],"b":"text"}`
+
+	o, err := NewObjectFromReader(strings.NewReader(doc), int64(len(doc)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.OptString("b") != "text" {
+		t.Errorf("unexpected object: %s", o.ToString())
+	}
+
+	_, err = NewObjectFromReader(strings.NewReader(doc), int64(len(doc)-1))
+	if _, ok := err.(TooLargeError); !ok {
+		t.Errorf("expected TooLargeError, got %v", err)
+	}
+
+	if _, err = NewObjectFromReader(strings.NewReader(doc + `{}`)); err == nil {
+		t.Error("trailing data should not be accepted")
+	}
+
+	a, err := NewArrayFromReader(strings.NewReader(`[{"x":1}]`), 1024)
+	if err != nil || a.OptObject(0).OptInt("x") != 1 {
+		t.Errorf("unexpected array result: %v", err)
+	}
+}
+
+func TestUseNumber(t *testing.T) {
+	doc := []byte(`{"id":9007199254740993,"price":1.5,"neg":-1,"n":[18446744073709551615]}`)
+
+	o, err := NewObjectFromBytesWithOptions(doc, ParseOptions{UseNumber: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := o.GetLong("id"); id != 9007199254740993 {
+		t.Errorf("id was rounded: %d", id)
+	}
+	if d, _ := o.GetDouble("price"); d != 1.5 {
+		t.Errorf("unexpected price %v", d)
+	}
+	if _, err := o.GetUint64("neg"); err == nil {
+		t.Error("negative value should not convert to uint64")
+	}
+	if _, err := o.GetBigInt("price"); err == nil {
+		t.Error("fractional value should not convert to big int")
+	}
+	if u, err := o.OptArray("n").GetUint64(0); err != nil || u != 18446744073709551615 {
+		t.Errorf("unexpected uint64 %d %v", u, err)
+	}
+	if v, _ := o.Increment("id"); v != 9007199254740994 {
+		t.Errorf("unexpected increment result %d", v)
+	}
+	if s := o.ToString(); !strings.Contains(s, `"id":9007199254740994`) {
+		t.Errorf("number not preserved in output: %s", s)
+	}
+}
@@ -0,0 +1,194 @@
+package schema
+
+import (
+	"sort"
+
+	jsonlight "github.com/rshmelev/go-json-light"
+)
+
+const draft202012 = "https://json-schema.org/draft/2020-12/schema"
+
+// strings become enum only if there are few distinct values
+// and each of them is seen at least twice on average
+const inferEnumLimit = 8
+
+// formats which are detected when every string value matches
+var inferredFormats = []string{"date-time", "date", "uuid", "email"}
+
+// builds schema which accepts all samples.
+// keys present in every sample object are required, others are optional.
+// strings get "format" when all of them match one, or "enum" when they look like a closed set
+// and there are no values of other types except null
+func InferSchema(samples ...jsonlight.IReadonlyObject) jsonlight.IObject {
+	st := newInferStats()
+	for _, s := range samples {
+		if s != nil {
+			st.add(s)
+		}
+	}
+	o := jsonlight.NewOrderedObject()
+	o.Put("$schema", draft202012)
+	if st.objects == 0 {
+		o.Put("type", "object")
+		return o
+	}
+	res := st.schema()
+	for _, k := range res.Keys() {
+		v, _ := res.Get(k)
+		o.Put(k, v)
+	}
+	return o
+}
+
+// everything observed at one position of the samples
+type inferStats struct {
+	types map[string]bool
+
+	strings     []string
+	distinct    map[string]bool
+	formats     map[string]bool
+	stringCount int
+
+	objects    int
+	props      map[string]*inferStats
+	propCounts map[string]int
+	propOrder  []string
+
+	items *inferStats
+}
+
+func newInferStats() *inferStats {
+	st := &inferStats{types: map[string]bool{}, distinct: map[string]bool{}, formats: map[string]bool{}}
+	for _, f := range inferredFormats {
+		st.formats[f] = true
+	}
+	return st
+}
+
+func (this *inferStats) add(v interface{}) {
+	t := TypeOf(v)
+	this.types[t] = true
+	switch t {
+	case "string":
+		this.addString(v.(string))
+	case "object":
+		o, _ := jsonlight.ReadonlyObjectValue(v)
+		this.addObject(o)
+	case "array":
+		s, _ := jsonlight.SliceValue(v)
+		if this.items == nil {
+			this.items = newInferStats()
+		}
+		for _, x := range s {
+			this.items.add(x)
+		}
+	}
+}
+
+func (this *inferStats) addString(s string) {
+	this.stringCount++
+	if len(this.distinct) <= inferEnumLimit && !this.distinct[s] {
+		this.distinct[s] = true
+		this.strings = append(this.strings, s)
+	}
+	for f := range this.formats {
+		if !FormatCheckers[f](s) {
+			delete(this.formats, f)
+		}
+	}
+}
+
+func (this *inferStats) addObject(o jsonlight.IReadonlyObject) {
+	if this.props == nil {
+		this.props = map[string]*inferStats{}
+		this.propCounts = map[string]int{}
+	}
+	this.objects++
+	keys := o.Keys()
+	if _, ordered := o.(*jsonlight.OrderedObject); !ordered {
+		sort.Strings(keys)
+	}
+	for _, k := range keys {
+		p, ok := this.props[k]
+		if !ok {
+			p = newInferStats()
+			this.props[k] = p
+			this.propOrder = append(this.propOrder, k)
+		}
+		this.propCounts[k]++
+		v, _ := o.Get(k)
+		p.add(v)
+	}
+}
+
+func (this *inferStats) schema() jsonlight.IObject {
+	res := jsonlight.NewOrderedObject()
+
+	types := make([]interface{}, 0)
+	for _, t := range []string{"null", "boolean", "integer", "number", "string", "array", "object"} {
+		// integer is subset of number
+		if this.types[t] && !(t == "integer" && this.types["number"]) {
+			types = append(types, t)
+		}
+	}
+	if len(types) == 1 {
+		res.Put("type", types[0])
+	} else if len(types) > 1 {
+		res.Put("type", jsonlight.NewArrayFromSlice(types))
+	}
+
+	if this.stringCount > 0 {
+		if f := this.format(); f != "" {
+			res.Put("format", f)
+		} else if this.onlyStrings() && len(this.distinct) <= inferEnumLimit && this.stringCount >= 2*len(this.distinct) {
+			enum := make([]interface{}, 0, len(this.strings)+1)
+			for _, s := range this.strings {
+				enum = append(enum, s)
+			}
+			if this.types["null"] {
+				enum = append(enum, nil)
+			}
+			res.Put("enum", jsonlight.NewArrayFromSlice(enum))
+		}
+	}
+
+	if this.objects > 0 {
+		props := jsonlight.NewOrderedObject()
+		required := make([]interface{}, 0)
+		for _, k := range this.propOrder {
+			props.Put(k, this.props[k].schema())
+			if this.propCounts[k] == this.objects {
+				required = append(required, k)
+			}
+		}
+		res.Put("properties", props)
+		if len(required) > 0 {
+			res.Put("required", jsonlight.NewArrayFromSlice(required))
+		}
+	}
+
+	if this.items != nil && len(this.items.types) > 0 {
+		res.Put("items", this.items.schema())
+	}
+	return res
+}
+
+// enum would reject values of other types, so it is used only for strings and nulls
+func (this *inferStats) onlyStrings() bool {
+	for t := range this.types {
+		if t != "string" && t != "null" {
+			return false
+		}
+	}
+	return true
+}
+
+// first of inferredFormats matched by all strings
+func (this *inferStats) format() string {
+	for _, f := range inferredFormats {
+		if this.formats[f] {
+			return f
+		}
+	}
+	return ""
+}
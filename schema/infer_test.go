@@ -0,0 +1,76 @@
+package schema
+
+import (
+	"testing"
+
+	jsonlight "github.com/rshmelev/go-json-light"
+)
+
+func TestInferSchema(t *testing.T) {
+	samples := []jsonlight.IReadonlyObject{
+		jsonlight.NewObjectOrDie(`{"id":"0b7a5e1c-3f0a-4a57-9c59-2d6f1f1b8e11","n":1,"status":"new","at":"2024-01-02T03:04:05Z","tags":["a"]}`),
+		jsonlight.NewObjectOrDie(`{"id":"6c1c8f4e-0e2b-4c3b-8a36-4c2f36a7d0a2","n":2.5,"status":"done","mail":"x@y.z","tags":[]}`),
+		jsonlight.NewObjectOrDie(`{"id":"d3b07384-d9a7-4f3b-9f1e-7a4e0e2c7a10","n":3,"status":"new","at":null,"tags":["b",1]}`),
+		jsonlight.NewObjectOrDie(`{"id":"1e6d5c92-8f43-4b1e-a0c3-5b9e8f2a4d67","n":4,"status":"done","tags":[]}`),
+	}
+	s := InferSchema(samples...)
+
+	expected := map[string]string{
+		"/type":                       `"object"`,
+		"/required":                   `["id","n","status","tags"]`,
+		"/properties/id/format":       `"uuid"`,
+		"/properties/n/type":          `"number"`,
+		"/properties/status/enum":     `["new","done"]`,
+		"/properties/at/type":         `["null","string"]`,
+		"/properties/at/format":       `"date-time"`,
+		"/properties/mail/format":     `"email"`,
+		"/properties/tags/items/type": `["integer","string"]`,
+	}
+	for p, v := range expected {
+		got, ok := s.GetPointer(p)
+		if !ok || jsonString(got) != v {
+			t.Errorf("%s: expected %s, got %s", p, v, jsonString(got))
+		}
+	}
+
+	compiled, err := Compile(s, Options{AssertFormat: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sample := range samples {
+		if err := compiled.Validate(sample); err != nil {
+			t.Error(err)
+		}
+	}
+	if compiled.IsValid(jsonlight.NewObjectOrDie(`{"id":"x","n":1,"status":"new","tags":[]}`)) {
+		t.Error("bad uuid should not be valid")
+	}
+}
+
+func TestInferSchemaMixedTypes(t *testing.T) {
+	samples := []jsonlight.IReadonlyObject{}
+	for _, v := range []string{`"a"`, `"a"`, `"b"`, `"b"`, `1`} {
+		samples = append(samples, jsonlight.NewObjectOrDie(`{"v":`+v+`}`))
+	}
+	s := InferSchema(samples...)
+	if s.HasPointer("/properties/v/enum") {
+		t.Errorf("enum for mixed types: %s", s.ToString())
+	}
+	compiled, err := Compile(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sample := range samples {
+		if err := compiled.Validate(sample); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestInferSchemaNestedOrder(t *testing.T) {
+	o, _ := jsonlight.NewOrderedObjectFromString(`{"inner":{"z":1,"a":2}}`)
+	s := InferSchema(o.OptObject("inner"))
+	if keys := s.OptObject("properties").Keys(); len(keys) != 2 || keys[0] != "z" || keys[1] != "a" {
+		t.Errorf("order of nested ordered object not kept: %v", keys)
+	}
+}
@@ -0,0 +1,967 @@
+// JSON Schema (draft 2020-12) validation of jsonlight objects and arrays.
+// only local references are supported: "#", "#/$defs/x" and "#anchor",
+// nothing is ever downloaded
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	jsonlight "github.com/rshmelev/go-json-light"
+)
+
+type Options struct {
+	// treat "format" as assertion, by default it is annotation only.
+	// formats are checked with FormatCheckers
+	AssertFormat bool
+}
+
+type Schema struct {
+	root *node
+	opts Options
+}
+
+// single violation. InstancePath points to the invalid value,
+// SchemaPath to the failed keyword, both are JSON Pointers
+type ValidationError struct {
+	InstancePath string
+	SchemaPath   string
+	Keyword      string
+	Message      string
+}
+
+func (a ValidationError) Error() string {
+	path := a.InstancePath
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s (%s)", path, a.Message, a.SchemaPath)
+}
+
+// returned by Validate, contains every violation found
+type ValidationErrors []ValidationError
+
+func (a ValidationErrors) Error() string {
+	res := make([]string, len(a))
+	for i, e := range a {
+		res[i] = e.Error()
+	}
+	return strings.Join(res, "; ")
+}
+
+func Compile(o jsonlight.IReadonlyObject, opts ...Options) (*Schema, error) {
+	if o == nil {
+		return nil, errors.New("schema: nil schema")
+	}
+	// plain maps with exact numbers are easier to walk
+	raw, err := jsonlight.NewObjectFromBytesWithOptions(o.ToByteArray(), jsonlight.ParseOptions{UseNumber: true})
+	if err != nil {
+		return nil, err
+	}
+	return compileRaw(raw.ToMap(), opts...)
+}
+
+func CompileString(str string, opts ...Options) (*Schema, error) {
+	raw, err := jsonlight.NewObjectFromBytesWithOptions([]byte(str), jsonlight.ParseOptions{UseNumber: true})
+	if err != nil {
+		return nil, err
+	}
+	return compileRaw(raw.ToMap(), opts...)
+}
+
+func MustCompile(o jsonlight.IReadonlyObject, opts ...Options) *Schema {
+	s, err := Compile(o, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func compileRaw(raw map[string]interface{}, opts ...Options) (*Schema, error) {
+	c := &compiler{root: raw, nodes: map[string]*node{}, anchors: map[string]*node{}}
+	if id, ok := raw["$id"].(string); ok {
+		c.id = strings.TrimSuffix(id, "#")
+	}
+	root, err := c.compile(raw, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := c.resolveRefs(); err != nil {
+		return nil, err
+	}
+	s := &Schema{root: root}
+	if len(opts) > 0 {
+		s.opts = opts[0]
+	}
+	return s, nil
+}
+
+// instance can be IObject, IArray or any raw value.
+// returns nil or ValidationErrors
+func (this *Schema) Validate(instance interface{}) error {
+	v := &validator{opts: this.opts}
+	errs, _ := v.validate(this.root, instance, "")
+	if len(errs) == 0 {
+		return nil
+	}
+	return ValidationErrors(errs)
+}
+
+func (this *Schema) IsValid(instance interface{}) bool {
+	return this.Validate(instance) == nil
+}
+
+//-------------------------------------
+// compilation
+
+type patternNode struct {
+	re   *regexp.Regexp
+	node *node
+}
+
+type node struct {
+	path    string
+	boolean *bool
+
+	ref     string
+	refNode *node
+
+	types    []string
+	enum     []interface{}
+	hasEnum  bool
+	constant interface{}
+	hasConst bool
+
+	multipleOf, maximum, exclusiveMaximum, minimum, exclusiveMinimum *big.Rat
+
+	maxLength, minLength         *int
+	pattern                      *regexp.Regexp
+	format                       string
+	maxItems, minItems           *int
+	uniqueItems                  bool
+	maxContains, minContains     *int
+	maxProperties, minProperties *int
+	required                     []string
+	dependentRequired            map[string][]string
+
+	allOf, anyOf, oneOf   []*node
+	not, ifs, then, elses *node
+
+	prefixItems []*node
+	items       *node
+	contains    *node
+
+	properties            map[string]*node
+	patternProperties     []patternNode
+	additionalProperties  *node
+	propertyNames         *node
+	dependentSchemas      map[string]*node
+	unevaluatedProperties *node
+	unevaluatedItems      *node
+}
+
+type compiler struct {
+	root    map[string]interface{}
+	id      string
+	nodes   map[string]*node
+	anchors map[string]*node
+}
+
+func (this *compiler) errorf(path string, format string, args ...interface{}) error {
+	return fmt.Errorf("schema: %s at %s", fmt.Sprintf(format, args...), path)
+}
+
+func (this *compiler) compile(raw interface{}, path string) (*node, error) {
+	if n, ok := this.nodes[path]; ok {
+		return n, nil
+	}
+	n := &node{path: path}
+	this.nodes[path] = n
+
+	if b, ok := raw.(bool); ok {
+		n.boolean = &b
+		return n, nil
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, this.errorf(path, "schema should be object or boolean")
+	}
+
+	if a, ok := m["$anchor"].(string); ok {
+		this.anchors[a] = n
+	}
+	if ref, ok := m["$ref"].(string); ok {
+		n.ref = ref
+	} else if ref, ok := m["$dynamicRef"].(string); ok {
+		// without remote schemas dynamic scope is the same document
+		n.ref = ref
+	}
+
+	for _, defs := range []string{"$defs", "definitions"} {
+		if d, ok := m[defs].(map[string]interface{}); ok {
+			for _, k := range sortedKeys(d) {
+				if _, err := this.compile(d[k], path+"/"+defs+"/"+jsonlight.EscapePointerToken(k)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if err := this.compileAssertions(n, m, path); err != nil {
+		return nil, err
+	}
+	if err := this.compileApplicators(n, m, path); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+func (this *compiler) compileAssertions(n *node, m map[string]interface{}, path string) error {
+	var err error
+	switch t := m["type"].(type) {
+	case nil:
+	case string:
+		n.types = []string{t}
+	case []interface{}:
+		for _, x := range t {
+			s, ok := x.(string)
+			if !ok {
+				return this.errorf(path+"/type", "type should be string")
+			}
+			n.types = append(n.types, s)
+		}
+	default:
+		return this.errorf(path+"/type", "type should be string or array")
+	}
+
+	if e, ok := m["enum"]; ok {
+		if n.enum, ok = e.([]interface{}); !ok {
+			return this.errorf(path+"/enum", "enum should be array")
+		}
+		n.hasEnum = true
+	}
+	n.constant, n.hasConst = m["const"]
+
+	rats := map[string]**big.Rat{
+		"multipleOf":       &n.multipleOf,
+		"maximum":          &n.maximum,
+		"exclusiveMaximum": &n.exclusiveMaximum,
+		"minimum":          &n.minimum,
+		"exclusiveMinimum": &n.exclusiveMinimum,
+	}
+	for k, dst := range rats {
+		if v, ok := m[k]; ok {
+			if *dst, ok = ratValue(v); !ok {
+				return this.errorf(path+"/"+k, "%s should be number", k)
+			}
+		}
+	}
+	if n.multipleOf != nil && n.multipleOf.Sign() <= 0 {
+		return this.errorf(path+"/multipleOf", "multipleOf should be positive")
+	}
+
+	ints := map[string]**int{
+		"maxLength":     &n.maxLength,
+		"minLength":     &n.minLength,
+		"maxItems":      &n.maxItems,
+		"minItems":      &n.minItems,
+		"maxContains":   &n.maxContains,
+		"minContains":   &n.minContains,
+		"maxProperties": &n.maxProperties,
+		"minProperties": &n.minProperties,
+	}
+	for k, dst := range ints {
+		if v, ok := m[k]; ok {
+			i, ok := jsonlight.IntValue(v)
+			if r, isNumber := ratValue(v); !ok || !isNumber || !r.IsInt() || i < 0 {
+				return this.errorf(path+"/"+k, "%s should be non-negative integer", k)
+			}
+			x := int(i)
+			*dst = &x
+		}
+	}
+
+	if p, ok := m["pattern"].(string); ok {
+		if n.pattern, err = regexp.Compile(p); err != nil {
+			return this.errorf(path+"/pattern", "bad pattern: %s", err)
+		}
+	}
+	n.format, _ = m["format"].(string)
+	n.uniqueItems, _ = m["uniqueItems"].(bool)
+
+	if r, ok := m["required"].([]interface{}); ok {
+		for _, x := range r {
+			s, ok := x.(string)
+			if !ok {
+				return this.errorf(path+"/required", "required should contain strings")
+			}
+			n.required = append(n.required, s)
+		}
+	}
+	if d, ok := m["dependentRequired"].(map[string]interface{}); ok {
+		n.dependentRequired = map[string][]string{}
+		for k, v := range d {
+			list, _ := v.([]interface{})
+			for _, x := range list {
+				if s, ok := x.(string); ok {
+					n.dependentRequired[k] = append(n.dependentRequired[k], s)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (this *compiler) compileApplicators(n *node, m map[string]interface{}, path string) error {
+	var err error
+	lists := map[string]*[]*node{"allOf": &n.allOf, "anyOf": &n.anyOf, "oneOf": &n.oneOf, "prefixItems": &n.prefixItems}
+	for k, dst := range lists {
+		v, ok := m[k]
+		if !ok {
+			continue
+		}
+		list, ok := v.([]interface{})
+		if !ok || (len(list) == 0 && k != "prefixItems") {
+			return this.errorf(path+"/"+k, "%s should be non-empty array", k)
+		}
+		for i, x := range list {
+			sub, err := this.compile(x, path+"/"+k+"/"+strconv.Itoa(i))
+			if err != nil {
+				return err
+			}
+			*dst = append(*dst, sub)
+		}
+	}
+
+	singles := map[string]**node{
+		"not":                   &n.not,
+		"if":                    &n.ifs,
+		"then":                  &n.then,
+		"else":                  &n.elses,
+		"items":                 &n.items,
+		"contains":              &n.contains,
+		"additionalProperties":  &n.additionalProperties,
+		"propertyNames":         &n.propertyNames,
+		"unevaluatedProperties": &n.unevaluatedProperties,
+		"unevaluatedItems":      &n.unevaluatedItems,
+	}
+	for k, dst := range singles {
+		if v, ok := m[k]; ok {
+			if *dst, err = this.compile(v, path+"/"+k); err != nil {
+				return err
+			}
+		}
+	}
+
+	maps := map[string]*map[string]*node{"properties": &n.properties, "dependentSchemas": &n.dependentSchemas}
+	for k, dst := range maps {
+		v, ok := m[k]
+		if !ok {
+			continue
+		}
+		props, ok := v.(map[string]interface{})
+		if !ok {
+			return this.errorf(path+"/"+k, "%s should be object", k)
+		}
+		*dst = map[string]*node{}
+		for name, x := range props {
+			if (*dst)[name], err = this.compile(x, path+"/"+k+"/"+jsonlight.EscapePointerToken(name)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if v, ok := m["patternProperties"]; ok {
+		props, ok := v.(map[string]interface{})
+		if !ok {
+			return this.errorf(path+"/patternProperties", "patternProperties should be object")
+		}
+		for _, p := range sortedKeys(props) {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return this.errorf(path+"/patternProperties", "bad pattern: %s", err)
+			}
+			sub, err := this.compile(props[p], path+"/patternProperties/"+jsonlight.EscapePointerToken(p))
+			if err != nil {
+				return err
+			}
+			n.patternProperties = append(n.patternProperties, patternNode{re: re, node: sub})
+		}
+	}
+	return nil
+}
+
+func (this *compiler) resolveRefs() error {
+	// resolving may compile new nodes, so iterate over snapshot until nothing changes
+	for {
+		pending := make([]*node, 0)
+		for _, n := range this.nodes {
+			if n.ref != "" && n.refNode == nil {
+				pending = append(pending, n)
+			}
+		}
+		if len(pending) == 0 {
+			return this.checkRefCycles()
+		}
+		for _, n := range pending {
+			target, err := this.resolveRef(n.ref, n.path)
+			if err != nil {
+				return err
+			}
+			n.refNode = target
+		}
+	}
+}
+
+// validation follows $ref and in-place applicators (allOf, not, if, ...) on the same
+// instance, so a chain of them which comes back to itself would never end
+func (this *compiler) checkRefCycles() error {
+	// 1 - on current path, 2 - done
+	state := map[*node]int{}
+	var visit func(n *node) error
+	visit = func(n *node) error {
+		switch state[n] {
+		case 1:
+			return this.errorf(n.path, "reference cycle")
+		case 2:
+			return nil
+		}
+		state[n] = 1
+		for _, next := range n.inPlace() {
+			if err := visit(next); err != nil {
+				return err
+			}
+		}
+		state[n] = 2
+		return nil
+	}
+	for _, path := range sortedKeys(this.nodes) {
+		if err := visit(this.nodes[path]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// subschemas applied to the same instance as the node itself
+func (this *node) inPlace() []*node {
+	res := []*node{}
+	for _, n := range []*node{this.refNode, this.not, this.ifs, this.then, this.elses} {
+		if n != nil {
+			res = append(res, n)
+		}
+	}
+	res = append(res, this.allOf...)
+	res = append(res, this.anyOf...)
+	res = append(res, this.oneOf...)
+	for _, k := range sortedKeys(this.dependentSchemas) {
+		res = append(res, this.dependentSchemas[k])
+	}
+	return res
+}
+
+func (this *compiler) resolveRef(ref string, path string) (*node, error) {
+	if this.id != "" && strings.HasPrefix(ref, this.id) {
+		ref = ref[len(this.id):]
+	}
+	if ref == "" || ref == "#" {
+		return this.nodes[""], nil
+	}
+	if !strings.HasPrefix(ref, "#") {
+		return nil, this.errorf(path+"/$ref", "only local references are supported: %s", ref)
+	}
+	fragment, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, this.errorf(path+"/$ref", "bad reference %s", ref)
+	}
+	if !strings.HasPrefix(fragment, "/") {
+		if n, ok := this.anchors[fragment]; ok {
+			return n, nil
+		}
+		return nil, this.errorf(path+"/$ref", "unknown anchor %s", ref)
+	}
+
+	if n, ok := this.nodes[fragment]; ok {
+		return n, nil
+	}
+	tokens, err := jsonlight.ParsePointer(fragment)
+	if err != nil {
+		return nil, this.errorf(path+"/$ref", "bad reference %s", ref)
+	}
+	var cur interface{} = this.root
+	for _, t := range tokens {
+		switch c := cur.(type) {
+		case map[string]interface{}:
+			cur = c[t]
+		case []interface{}:
+			i, err := strconv.Atoi(t)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, this.errorf(path+"/$ref", "unresolvable reference %s", ref)
+			}
+			cur = c[i]
+		default:
+			cur = nil
+		}
+		if cur == nil {
+			return nil, this.errorf(path+"/$ref", "unresolvable reference %s", ref)
+		}
+	}
+	return this.compile(cur, fragment)
+}
+
+//-------------------------------------
+// validation
+
+// evaluated properties and items, needed for unevaluated* keywords
+type annotations struct {
+	props    map[string]bool
+	items    int
+	allItems bool
+	indexes  map[int]bool
+}
+
+func (this *annotations) merge(other *annotations) {
+	if other == nil {
+		return
+	}
+	for k := range other.props {
+		this.props[k] = true
+	}
+	for i := range other.indexes {
+		this.indexes[i] = true
+	}
+	if other.items > this.items {
+		this.items = other.items
+	}
+	this.allItems = this.allItems || other.allItems
+}
+
+func newAnnotations() *annotations {
+	return &annotations{props: map[string]bool{}, indexes: map[int]bool{}}
+}
+
+// false schema is reported by keyword which holds it, e.g. additionalProperties
+func owningKeyword(path string) string {
+	tokens := strings.Split(path, "/")
+	for i := len(tokens) - 1; i > 0; i-- {
+		switch tokens[i-1] {
+		case "properties", "patternProperties", "dependentSchemas":
+			return tokens[i-1]
+		}
+		switch tokens[i] {
+		case "additionalProperties", "unevaluatedProperties", "unevaluatedItems", "items", "contains",
+			"propertyNames", "not", "then", "else", "prefixItems", "allOf", "anyOf", "oneOf":
+			return tokens[i]
+		}
+	}
+	return "false"
+}
+
+type validator struct {
+	opts Options
+}
+
+func fail(n *node, ipath, keyword, format string, args ...interface{}) ValidationError {
+	return ValidationError{
+		InstancePath: ipath,
+		SchemaPath:   n.path + "/" + keyword,
+		Keyword:      keyword,
+		Message:      fmt.Sprintf(format, args...),
+	}
+}
+
+func (this *validator) validate(n *node, inst interface{}, ipath string) ([]ValidationError, *annotations) {
+	ann := newAnnotations()
+	errs := make([]ValidationError, 0)
+
+	if n.boolean != nil {
+		if !*n.boolean {
+			errs = append(errs, ValidationError{InstancePath: ipath, SchemaPath: n.path, Keyword: owningKeyword(n.path), Message: "no value is allowed here"})
+		}
+		return errs, ann
+	}
+
+	if n.refNode != nil {
+		e, a := this.validate(n.refNode, inst, ipath)
+		errs = append(errs, e...)
+		ann.merge(a)
+	}
+
+	errs = append(errs, this.validateGeneric(n, inst, ipath)...)
+	errs = append(errs, this.validateApplicators(n, inst, ipath, ann)...)
+
+	if o, ok := jsonlight.ReadonlyObjectValue(inst); ok {
+		errs = append(errs, this.validateObject(n, o, ipath, ann)...)
+	} else if s, ok := jsonlight.SliceValue(inst); ok {
+		errs = append(errs, this.validateArray(n, s, ipath, ann)...)
+	} else if str, ok := inst.(string); ok {
+		errs = append(errs, this.validateString(n, str, ipath)...)
+	} else if r, ok := ratValue(inst); ok {
+		errs = append(errs, this.validateNumber(n, r, ipath)...)
+	}
+
+	if len(errs) > 0 {
+		return errs, nil
+	}
+	return errs, ann
+}
+
+func (this *validator) validateGeneric(n *node, inst interface{}, ipath string) []ValidationError {
+	errs := make([]ValidationError, 0)
+	if len(n.types) > 0 {
+		actual := TypeOf(inst)
+		matched := false
+		for _, t := range n.types {
+			if t == actual || (t == "number" && actual == "integer") {
+				matched = true
+			}
+		}
+		if !matched {
+			errs = append(errs, fail(n, ipath, "type", "expected %s, got %s", strings.Join(n.types, " or "), actual))
+		}
+	}
+	if n.hasEnum {
+		found := false
+		for _, e := range n.enum {
+			if jsonlight.ValuesEqual(e, inst) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, fail(n, ipath, "enum", "value is not one of enumerated values"))
+		}
+	}
+	if n.hasConst && !jsonlight.ValuesEqual(n.constant, inst) {
+		errs = append(errs, fail(n, ipath, "const", "value should be equal to %s", jsonString(n.constant)))
+	}
+	return errs
+}
+
+func (this *validator) validateApplicators(n *node, inst interface{}, ipath string, ann *annotations) []ValidationError {
+	errs := make([]ValidationError, 0)
+
+	for _, sub := range n.allOf {
+		e, a := this.validate(sub, inst, ipath)
+		errs = append(errs, e...)
+		ann.merge(a)
+	}
+
+	if len(n.anyOf) > 0 {
+		matched := false
+		for _, sub := range n.anyOf {
+			if e, a := this.validate(sub, inst, ipath); len(e) == 0 {
+				matched = true
+				ann.merge(a)
+			}
+		}
+		if !matched {
+			errs = append(errs, fail(n, ipath, "anyOf", "value does not match any schema"))
+		}
+	}
+
+	if len(n.oneOf) > 0 {
+		matched := 0
+		var matchedAnn *annotations
+		for _, sub := range n.oneOf {
+			if e, a := this.validate(sub, inst, ipath); len(e) == 0 {
+				matched++
+				matchedAnn = a
+			}
+		}
+		if matched != 1 {
+			errs = append(errs, fail(n, ipath, "oneOf", "value should match exactly one schema, matched %d", matched))
+		} else {
+			ann.merge(matchedAnn)
+		}
+	}
+
+	if n.not != nil {
+		if e, _ := this.validate(n.not, inst, ipath); len(e) == 0 {
+			errs = append(errs, fail(n, ipath, "not", "value should not match schema"))
+		}
+	}
+
+	if n.ifs != nil {
+		if e, a := this.validate(n.ifs, inst, ipath); len(e) == 0 {
+			ann.merge(a)
+			if n.then != nil {
+				e, a := this.validate(n.then, inst, ipath)
+				errs = append(errs, e...)
+				ann.merge(a)
+			}
+		} else if n.elses != nil {
+			e, a := this.validate(n.elses, inst, ipath)
+			errs = append(errs, e...)
+			ann.merge(a)
+		}
+	}
+	return errs
+}
+
+func (this *validator) validateObject(n *node, o jsonlight.IReadonlyObject, ipath string, ann *annotations) []ValidationError {
+	errs := make([]ValidationError, 0)
+	keys := o.Keys()
+	sort.Strings(keys)
+
+	if n.maxProperties != nil && len(keys) > *n.maxProperties {
+		errs = append(errs, fail(n, ipath, "maxProperties", "object should have at most %d properties", *n.maxProperties))
+	}
+	if n.minProperties != nil && len(keys) < *n.minProperties {
+		errs = append(errs, fail(n, ipath, "minProperties", "object should have at least %d properties", *n.minProperties))
+	}
+	for _, r := range n.required {
+		if !o.Has(r) {
+			errs = append(errs, fail(n, ipath, "required", "missing required property %q", r))
+		}
+	}
+	for _, k := range sortedKeys(n.dependentRequired) {
+		if !o.Has(k) {
+			continue
+		}
+		for _, r := range n.dependentRequired[k] {
+			if !o.Has(r) {
+				errs = append(errs, fail(n, ipath, "dependentRequired", "property %q is required when %q is present", r, k))
+			}
+		}
+	}
+	for _, k := range sortedKeys(n.dependentSchemas) {
+		if o.Has(k) {
+			e, a := this.validate(n.dependentSchemas[k], o, ipath)
+			errs = append(errs, e...)
+			ann.merge(a)
+		}
+	}
+
+	for _, k := range keys {
+		v, _ := o.Get(k)
+		kpath := ipath + "/" + jsonlight.EscapePointerToken(k)
+		matched := false
+		if sub, ok := n.properties[k]; ok {
+			matched = true
+			e, _ := this.validate(sub, v, kpath)
+			errs = append(errs, e...)
+		}
+		for _, p := range n.patternProperties {
+			if p.re.MatchString(k) {
+				matched = true
+				e, _ := this.validate(p.node, v, kpath)
+				errs = append(errs, e...)
+			}
+		}
+		if !matched && n.additionalProperties != nil {
+			matched = true
+			e, _ := this.validate(n.additionalProperties, v, kpath)
+			errs = append(errs, e...)
+		}
+		if matched {
+			ann.props[k] = true
+		}
+		if n.propertyNames != nil {
+			e, _ := this.validate(n.propertyNames, k, kpath)
+			errs = append(errs, e...)
+		}
+	}
+
+	if n.unevaluatedProperties != nil {
+		for _, k := range keys {
+			if ann.props[k] {
+				continue
+			}
+			v, _ := o.Get(k)
+			e, _ := this.validate(n.unevaluatedProperties, v, ipath+"/"+jsonlight.EscapePointerToken(k))
+			errs = append(errs, e...)
+			ann.props[k] = true
+		}
+	}
+	return errs
+}
+
+func (this *validator) validateArray(n *node, s []interface{}, ipath string, ann *annotations) []ValidationError {
+	errs := make([]ValidationError, 0)
+
+	if n.maxItems != nil && len(s) > *n.maxItems {
+		errs = append(errs, fail(n, ipath, "maxItems", "array should have at most %d items", *n.maxItems))
+	}
+	if n.minItems != nil && len(s) < *n.minItems {
+		errs = append(errs, fail(n, ipath, "minItems", "array should have at least %d items", *n.minItems))
+	}
+	if n.uniqueItems {
+	unique:
+		for i := 0; i < len(s); i++ {
+			for j := i + 1; j < len(s); j++ {
+				if jsonlight.ValuesEqual(s[i], s[j]) {
+					errs = append(errs, fail(n, ipath, "uniqueItems", "items %d and %d are equal", i, j))
+					break unique
+				}
+			}
+		}
+	}
+
+	for i, sub := range n.prefixItems {
+		if i >= len(s) {
+			break
+		}
+		e, _ := this.validate(sub, s[i], ipath+"/"+strconv.Itoa(i))
+		errs = append(errs, e...)
+		if i+1 > ann.items {
+			ann.items = i + 1
+		}
+	}
+	if n.items != nil {
+		for i := len(n.prefixItems); i < len(s); i++ {
+			e, _ := this.validate(n.items, s[i], ipath+"/"+strconv.Itoa(i))
+			errs = append(errs, e...)
+		}
+		ann.allItems = true
+	}
+
+	if n.contains != nil {
+		count := 0
+		for i, v := range s {
+			if e, _ := this.validate(n.contains, v, ipath+"/"+strconv.Itoa(i)); len(e) == 0 {
+				count++
+				ann.indexes[i] = true
+			}
+		}
+		min := 1
+		if n.minContains != nil {
+			min = *n.minContains
+		}
+		if count < min {
+			errs = append(errs, fail(n, ipath, "contains", "array should contain at least %d matching items, found %d", min, count))
+		}
+		if n.maxContains != nil && count > *n.maxContains {
+			errs = append(errs, fail(n, ipath, "maxContains", "array should contain at most %d matching items, found %d", *n.maxContains, count))
+		}
+	}
+
+	if n.unevaluatedItems != nil && !ann.allItems {
+		for i := ann.items; i < len(s); i++ {
+			if ann.indexes[i] {
+				continue
+			}
+			e, _ := this.validate(n.unevaluatedItems, s[i], ipath+"/"+strconv.Itoa(i))
+			errs = append(errs, e...)
+		}
+		ann.allItems = true
+	}
+	return errs
+}
+
+func (this *validator) validateString(n *node, s string, ipath string) []ValidationError {
+	errs := make([]ValidationError, 0)
+	length := utf8.RuneCountInString(s)
+	if n.maxLength != nil && length > *n.maxLength {
+		errs = append(errs, fail(n, ipath, "maxLength", "string should be at most %d characters long", *n.maxLength))
+	}
+	if n.minLength != nil && length < *n.minLength {
+		errs = append(errs, fail(n, ipath, "minLength", "string should be at least %d characters long", *n.minLength))
+	}
+	if n.pattern != nil && !n.pattern.MatchString(s) {
+		errs = append(errs, fail(n, ipath, "pattern", "string should match pattern %s", n.pattern))
+	}
+	if this.opts.AssertFormat && n.format != "" {
+		if check, ok := FormatCheckers[n.format]; ok && !check(s) {
+			errs = append(errs, fail(n, ipath, "format", "string is not valid %s", n.format))
+		}
+	}
+	return errs
+}
+
+func (this *validator) validateNumber(n *node, r *big.Rat, ipath string) []ValidationError {
+	errs := make([]ValidationError, 0)
+	if n.multipleOf != nil && !new(big.Rat).Quo(r, n.multipleOf).IsInt() {
+		errs = append(errs, fail(n, ipath, "multipleOf", "value should be multiple of %s", n.multipleOf.RatString()))
+	}
+	if n.maximum != nil && r.Cmp(n.maximum) > 0 {
+		errs = append(errs, fail(n, ipath, "maximum", "value should be <= %s", n.maximum.RatString()))
+	}
+	if n.exclusiveMaximum != nil && r.Cmp(n.exclusiveMaximum) >= 0 {
+		errs = append(errs, fail(n, ipath, "exclusiveMaximum", "value should be < %s", n.exclusiveMaximum.RatString()))
+	}
+	if n.minimum != nil && r.Cmp(n.minimum) < 0 {
+		errs = append(errs, fail(n, ipath, "minimum", "value should be >= %s", n.minimum.RatString()))
+	}
+	if n.exclusiveMinimum != nil && r.Cmp(n.exclusiveMinimum) <= 0 {
+		errs = append(errs, fail(n, ipath, "exclusiveMinimum", "value should be > %s", n.exclusiveMinimum.RatString()))
+	}
+	return errs
+}
+
+//-------------------------------------
+// helpers
+
+// JSON type name of the value: null, boolean, integer, number, string, array or object
+func TypeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	}
+	if _, ok := jsonlight.ReadonlyObjectValue(v); ok {
+		return "object"
+	}
+	if _, ok := jsonlight.SliceValue(v); ok {
+		return "array"
+	}
+	if r, ok := ratValue(v); ok {
+		if r.IsInt() {
+			return "integer"
+		}
+		return "number"
+	}
+	return "unknown"
+}
+
+// exact value of any number
+func ratValue(v interface{}) (*big.Rat, bool) {
+	switch n := v.(type) {
+	case bool, string, nil:
+		return nil, false
+	case json.Number:
+		return new(big.Rat).SetString(string(n))
+	}
+	if b, ok := jsonlight.BigIntValue(v); ok {
+		return new(big.Rat).SetInt(b), true
+	}
+	if f, ok := jsonlight.FloatValue(v); ok {
+		r := new(big.Rat)
+		if r.SetFloat64(f) == nil {
+			return nil, false
+		}
+		return r, true
+	}
+	return nil, false
+}
+
+// sorted keys of any map with string keys
+func sortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	res := make([]string, len(keys))
+	for i, k := range keys {
+		res[i] = k.String()
+	}
+	sort.Strings(res)
+	return res
+}
+
+func jsonString(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
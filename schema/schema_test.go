@@ -0,0 +1,120 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+
+	jsonlight "github.com/rshmelev/go-json-light"
+)
+
+const personSchema = `{
+	"$defs": {
+		"positive": {"type": "integer", "exclusiveMinimum": 0},
+		"tag": {"$anchor": "tag", "type": "string", "minLength": 1}
+	},
+	"type": "object",
+	"required": ["name", "age"],
+	"properties": {
+		"name": {"type": "string"},
+		"age": {"$ref": "#/$defs/positive"},
+		"tags": {"type": "array", "items": {"$ref": "#tag"}, "uniqueItems": true},
+		"kind": {"enum": ["a", "b"]}
+	},
+	"unevaluatedProperties": false
+}`
+
+func TestValidate(t *testing.T) {
+	s, err := CompileString(personSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(jsonlight.NewObjectOrDie(`{"name":"x","age":3,"tags":["a","b"],"kind":"a"}`)); err != nil {
+		t.Error(err)
+	}
+
+	err = s.Validate(jsonlight.NewObjectOrDie(`{"age":0,"tags":["a","a",""],"kind":"c","extra":1}`))
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) {
+		t.Fatalf("expected ValidationErrors, got %v", err)
+	}
+	expected := map[string]string{
+		"":        "required",
+		"/age":    "exclusiveMinimum",
+		"/tags":   "uniqueItems",
+		"/tags/2": "minLength",
+		"/kind":   "enum",
+		"/extra":  "unevaluatedProperties",
+	}
+	for _, e := range verrs {
+		if kw, ok := expected[e.InstancePath]; !ok || kw != e.Keyword {
+			t.Errorf("unexpected error %+v", e)
+		}
+		delete(expected, e.InstancePath)
+	}
+	if len(expected) > 0 {
+		t.Errorf("missing errors for %v", expected)
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	s, err := CompileString(`{"oneOf":[{"type":"integer"},{"type":"number","multipleOf":0.5}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for v, valid := range map[interface{}]bool{1.5: true, 2.0: false, 0.3: false, "x": false} {
+		if s.IsValid(v) != valid {
+			t.Errorf("%v should be valid=%v", v, valid)
+		}
+	}
+
+	s, err = CompileString(`{"if":{"properties":{"t":{"const":"n"}}},"then":{"properties":{"v":{"type":"number"}}},"else":{"not":{"required":["v"]}}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.IsValid(jsonlight.NewObjectOrDie(`{"t":"n","v":1}`)) || s.IsValid(jsonlight.NewObjectOrDie(`{"t":"s","v":1}`)) {
+		t.Error("if/then/else failed")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	raw := `{"properties":{"id":{"format":"uuid"},"at":{"format":"date-time"},"mail":{"format":"email"}}}`
+	instance := jsonlight.NewObjectOrDie(`{"id":"nope","at":"2024-01-02T03:04:05Z","mail":"a@b.c"}`)
+	s, _ := CompileString(raw)
+	if !s.IsValid(instance) {
+		t.Error("format should be annotation by default")
+	}
+	s, _ = CompileString(raw, Options{AssertFormat: true})
+	err := s.Validate(instance)
+	if verrs, ok := err.(ValidationErrors); !ok || len(verrs) != 1 || verrs[0].InstancePath != "/id" {
+		t.Errorf("unexpected result %v", err)
+	}
+}
+
+func TestBadSchema(t *testing.T) {
+	if _, err := CompileString(`{"$ref":"#/$defs/missing"}`); err == nil {
+		t.Error("unresolved ref should fail")
+	}
+	if _, err := CompileString(`{"$ref":"http://example.com/s.json"}`); err == nil {
+		t.Error("remote ref should fail")
+	}
+	for _, doc := range []string{
+		`{"$defs":{"a":{"$ref":"#/$defs/a"}},"$ref":"#/$defs/a"}`,
+		`{"$defs":{"a":{"$ref":"#/$defs/b"},"b":{"$ref":"#"}},"$ref":"#/$defs/a"}`,
+		`{"allOf":[{"$ref":"#"}]}`,
+		`{"not":{"$ref":"#"}}`,
+		`{"if":true,"then":{"anyOf":[{"$ref":"#/$defs/a"}]},"$defs":{"a":{"dependentSchemas":{"x":{"$ref":"#"}}}}}`,
+	} {
+		if _, err := CompileString(doc); err == nil {
+			t.Errorf("ref cycle should fail: %s", doc)
+		}
+	}
+	// recursion through properties is fine
+	for _, doc := range []string{
+		`{"properties":{"next":{"$ref":"#"}}}`,
+		`{"anyOf":[{"type":"null"},{"items":{"$ref":"#"}}]}`,
+	} {
+		if _, err := CompileString(doc); err != nil {
+			t.Error(err)
+		}
+	}
+}
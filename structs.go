@@ -0,0 +1,887 @@
+package jsonlight
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// direct conversion between json values and go structs, without encoding to bytes.
+// rules are the same as in encoding/json: tags, omitempty, ",string", embedded structs,
+// json.Marshaler/Unmarshaler and encoding.TextMarshaler/TextUnmarshaler
+
+// failed field while filling struct, Path is JSON Pointer
+type FieldError struct {
+	Path string
+	Err  error
+}
+
+func (a FieldError) Error() string {
+	path := a.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s", path, a.Err)
+}
+
+func (a FieldError) Unwrap() error { return a.Err }
+
+// every failed field, sorted by path
+type FillErrors []FieldError
+
+func (a FillErrors) Error() string {
+	res := make([]string, len(a))
+	for i, e := range a {
+		res[i] = e.Error()
+	}
+	return strings.Join(res, "; ")
+}
+
+func (a FillErrors) Unwrap() []error {
+	res := make([]error, len(a))
+	for i, e := range a {
+		res[i] = e
+	}
+	return res
+}
+
+type UnknownKeyError struct{}
+type RequiredError struct{}
+
+func (a UnknownKeyError) Error() string { return "Unknown key" }
+func (a RequiredError) Error() string   { return "Required value is missing" }
+
+type FillOptions struct {
+	// "42" fills numbers, "true", "1", 1 and 0 fill bools, "1m30s" fills time.Duration,
+	// time.Time accepts TimeLayouts and unix seconds in addition to RFC 3339
+	Coerce bool
+	// keys which have no matching field are errors
+	DisallowUnknownKeys bool
+	// used with Coerce, default is DefaultTimeLayouts
+	TimeLayouts []string
+}
+
+var DefaultTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	numberType          = reflect.TypeOf(json.Number(""))
+	marshalerType       = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	unmarshalerType     = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+//-------------------------------------
+// per-type plans
+
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	quoted    bool
+	tagged    bool
+	required  bool
+}
+
+type structPlan struct {
+	fields []structField
+	byName map[string]*structField
+	// lowercase name -> field, for case-insensitive matching like in encoding/json
+	byFold   map[string]*structField
+	required []*structField
+}
+
+func (this *structPlan) lookup(key string) *structField {
+	if f, ok := this.byName[key]; ok {
+		return f
+	}
+	return this.byFold[strings.ToLower(key)]
+}
+
+var structPlans sync.Map // reflect.Type -> *structPlan
+
+func planFor(t reflect.Type) *structPlan {
+	if p, ok := structPlans.Load(t); ok {
+		return p.(*structPlan)
+	}
+	p, _ := structPlans.LoadOrStore(t, buildPlan(t))
+	return p.(*structPlan)
+}
+
+// embedded structs are walked breadth first, so shallower fields win.
+// fields with same name and depth hide each other unless exactly one is tagged
+func buildPlan(t reflect.Type) *structPlan {
+	type queued struct {
+		t     reflect.Type
+		index []int
+	}
+	type candidate struct {
+		structField
+		depth int
+	}
+
+	candidates := make([]candidate, 0)
+	visited := map[reflect.Type]bool{}
+	current := []queued{{t: t}}
+	for depth := 0; len(current) > 0; depth++ {
+		next := make([]queued, 0)
+		for _, q := range current {
+			if visited[q.t] {
+				continue
+			}
+			visited[q.t] = true
+			for i := 0; i < q.t.NumField(); i++ {
+				sf := q.t.Field(i)
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				ft := sf.Type
+				if sf.Anonymous && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if !sf.IsExported() && !(sf.Anonymous && ft.Kind() == reflect.Struct) {
+					continue
+				}
+
+				name, opts, _ := strings.Cut(tag, ",")
+				index := append(append([]int(nil), q.index...), i)
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, queued{t: ft, index: index})
+					continue
+				}
+				if !sf.IsExported() {
+					continue
+				}
+				f := structField{name: name, index: index, tagged: name != ""}
+				if name == "" {
+					f.name = sf.Name
+				}
+				for _, o := range strings.Split(opts, ",") {
+					switch o {
+					case "omitempty":
+						f.omitEmpty = true
+					case "required":
+						// ignored by encoding/json, checked by FillStructWithOptions
+						f.required = true
+					case "string":
+						switch sf.Type.Kind() {
+						case reflect.Bool, reflect.String,
+							reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+							reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+							reflect.Float32, reflect.Float64:
+							f.quoted = true
+						}
+					}
+				}
+				candidates = append(candidates, candidate{structField: f, depth: depth})
+			}
+		}
+		current = next
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		if a.depth != b.depth {
+			return a.depth < b.depth
+		}
+		return a.tagged && !b.tagged
+	})
+	plan := &structPlan{byName: map[string]*structField{}, byFold: map[string]*structField{}}
+	for i := 0; i < len(candidates); {
+		j := i + 1
+		for j < len(candidates) && candidates[j].name == candidates[i].name {
+			j++
+		}
+		first := candidates[i]
+		if j-i == 1 || first.depth != candidates[i+1].depth || first.tagged != candidates[i+1].tagged {
+			plan.fields = append(plan.fields, first.structField)
+		}
+		i = j
+	}
+
+	// encoding order is declaration order
+	sort.Slice(plan.fields, func(i, j int) bool {
+		a, b := plan.fields[i].index, plan.fields[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	for i := range plan.fields {
+		f := &plan.fields[i]
+		plan.byName[f.name] = f
+		lower := strings.ToLower(f.name)
+		if _, ok := plan.byFold[lower]; !ok {
+			plan.byFold[lower] = f
+		}
+		if f.required {
+			plan.required = append(plan.required, f)
+		}
+	}
+	return plan
+}
+
+const (
+	hasUnmarshaler = 1 << iota
+	hasTextUnmarshaler
+	hasMarshaler
+	hasTextMarshaler
+	hasPtrMarshaler
+	hasPtrTextMarshaler
+)
+
+var typeFlags sync.Map // reflect.Type -> int
+
+// Implements is too slow to call for every value
+func typeFlagsOf(t reflect.Type) int {
+	if f, ok := typeFlags.Load(t); ok {
+		return f.(int)
+	}
+	flags := 0
+	pt := reflect.PtrTo(t)
+	checks := []struct {
+		t     reflect.Type
+		iface reflect.Type
+		flag  int
+	}{
+		{pt, unmarshalerType, hasUnmarshaler},
+		{pt, textUnmarshalerType, hasTextUnmarshaler},
+		{t, marshalerType, hasMarshaler},
+		{t, textMarshalerType, hasTextMarshaler},
+		{pt, marshalerType, hasPtrMarshaler},
+		{pt, textMarshalerType, hasPtrTextMarshaler},
+	}
+	for _, c := range checks {
+		if c.t.Implements(c.iface) {
+			flags |= c.flag
+		}
+	}
+	typeFlags.Store(t, flags)
+	return flags
+}
+
+//-------------------------------------
+// decoding
+
+// fills value pointed by s, which is usually pointer to struct.
+// like json.Unmarshal it fills everything it can and returns the first error.
+// keys are not sorted while filling, so errors are sorted by path afterwards
+func fillStruct(v interface{}, s interface{}) error {
+	errs := fillStructWithOptions(v, s, nil)
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// unlike fillStruct returns all errors as FillErrors
+func fillStructWithOptions(v interface{}, s interface{}, opts *FillOptions) FillErrors {
+	rv := reflect.ValueOf(s)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return FillErrors{{Err: typeMismatch(nil, "non-nil pointer", s)}}
+	}
+	d := &decoder{opts: opts}
+	d.decode(v, rv.Elem())
+	if len(d.errors) > 0 {
+		sort.SliceStable(d.errors, func(i, j int) bool { return d.errors[i].Path < d.errors[j].Path })
+		return d.errors
+	}
+	return nil
+}
+
+type decoder struct {
+	// nil for plain FillStruct
+	opts   *FillOptions
+	path   []string
+	errors []FieldError
+}
+
+func (this *decoder) fail(err error) {
+	this.errors = append(this.errors, FieldError{Path: MakePointer(this.path...), Err: err})
+}
+
+func (this *decoder) mismatch(v interface{}, t reflect.Type) {
+	this.fail(typeMismatch(nil, t.String(), v))
+}
+
+func (this *decoder) decodeAt(token string, v interface{}, dst reflect.Value) {
+	this.path = append(this.path, token)
+	this.decode(v, dst)
+	this.path = this.path[:len(this.path)-1]
+}
+
+func (this *decoder) decode(v interface{}, dst reflect.Value) {
+	if isNil(&v) {
+		switch dst.Kind() {
+		case reflect.Interface, reflect.Ptr, reflect.Map, reflect.Slice:
+			dst.Set(reflect.Zero(dst.Type()))
+		}
+		return
+	}
+
+	t := dst.Type()
+	coerce := this.opts != nil && this.opts.Coerce
+	if coerce {
+		v = coerceValue(v, t)
+	}
+	if t == timeType {
+		if coerce {
+			if f, ok := NumberValue(v); ok {
+				sec, frac := math.Modf(f)
+				dst.Set(reflect.ValueOf(time.Unix(int64(sec), int64(frac*1e9))))
+				return
+			}
+		}
+		s, ok := v.(string)
+		if !ok {
+			this.mismatch(v, t)
+			return
+		}
+		tm, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil && coerce {
+			layouts := this.opts.TimeLayouts
+			if layouts == nil {
+				layouts = DefaultTimeLayouts
+			}
+			for _, layout := range layouts {
+				if tm, err = time.Parse(layout, s); err == nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			this.fail(err)
+			return
+		}
+		dst.Set(reflect.ValueOf(tm))
+		return
+	}
+	if t == numberType {
+		if jn, ok := v.(json.Number); ok {
+			dst.SetString(string(jn))
+		} else if b, ok := BigIntValue(v); ok {
+			dst.SetString(b.String())
+		} else if f, ok := NumberValue(v); ok {
+			dst.SetString(strconv.FormatFloat(f, 'g', -1, 64))
+		} else {
+			this.mismatch(v, t)
+		}
+		return
+	}
+	if t.Kind() != reflect.Ptr && dst.CanAddr() {
+		flags := typeFlagsOf(t)
+		if flags&hasUnmarshaler != 0 {
+			b, err := json.Marshal(v)
+			if err == nil {
+				err = dst.Addr().Interface().(json.Unmarshaler).UnmarshalJSON(b)
+			}
+			if err != nil {
+				this.fail(err)
+			}
+			return
+		}
+		if flags&hasTextUnmarshaler != 0 {
+			s, ok := v.(string)
+			if !ok {
+				this.mismatch(v, t)
+				return
+			}
+			if err := dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+				this.fail(err)
+			}
+			return
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(t.Elem()))
+		}
+		this.decode(v, dst.Elem())
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			// like encoding/json, non-empty interfaces can only be filled through existing pointer
+			if !dst.IsNil() && dst.Elem().Kind() == reflect.Ptr {
+				this.decode(v, dst.Elem())
+				return
+			}
+			this.mismatch(v, t)
+			return
+		}
+		dst.Set(reflect.ValueOf(DeepCopy(v)))
+	case reflect.Bool:
+		b, ok := v.(bool)
+		if !ok {
+			this.mismatch(v, t)
+			return
+		}
+		dst.SetBool(b)
+	case reflect.String:
+		s, ok := v.(string)
+		if !ok {
+			this.mismatch(v, t)
+			return
+		}
+		dst.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := exactInt(v)
+		if !ok || dst.OverflowInt(i) {
+			this.mismatch(v, t)
+			return
+		}
+		dst.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, ok := Uint64Value(v)
+		if !ok || dst.OverflowUint(u) {
+			this.mismatch(v, t)
+			return
+		}
+		dst.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, ok := NumberValue(v)
+		if !ok || dst.OverflowFloat(f) {
+			this.mismatch(v, t)
+			return
+		}
+		dst.SetFloat(f)
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			if s, ok := v.(string); ok {
+				b, err := base64.StdEncoding.DecodeString(s)
+				if err != nil {
+					this.fail(err)
+					return
+				}
+				dst.SetBytes(b)
+				return
+			}
+		}
+		s, ok := SliceValue(v)
+		if !ok {
+			this.mismatch(v, t)
+			return
+		}
+		res := reflect.MakeSlice(t, len(s), len(s))
+		for i, x := range s {
+			this.decodeAt(strconv.Itoa(i), x, res.Index(i))
+		}
+		dst.Set(res)
+	case reflect.Array:
+		s, ok := SliceValue(v)
+		if !ok {
+			this.mismatch(v, t)
+			return
+		}
+		for i := 0; i < dst.Len(); i++ {
+			if i < len(s) {
+				this.decodeAt(strconv.Itoa(i), s[i], dst.Index(i))
+			} else {
+				dst.Index(i).Set(reflect.Zero(t.Elem()))
+			}
+		}
+	case reflect.Map:
+		o, ok := ReadonlyObjectValue(v)
+		if !ok {
+			this.mismatch(v, t)
+			return
+		}
+		this.decodeMap(o, dst)
+	case reflect.Struct:
+		o, ok := ReadonlyObjectValue(v)
+		if !ok {
+			this.mismatch(v, t)
+			return
+		}
+		this.decodeStruct(o, dst)
+	default:
+		this.mismatch(v, t)
+	}
+}
+
+func (this *decoder) decodeMap(o IReadonlyObject, dst reflect.Value) {
+	t := dst.Type()
+	kt := t.Key()
+	if dst.IsNil() {
+		dst.Set(reflect.MakeMapWithSize(t, o.Length()))
+	}
+	for k, x := range o.ToMap() {
+		kv := reflect.New(kt).Elem()
+		switch {
+		case kt.Kind() == reflect.String:
+			kv.SetString(k)
+		case reflect.PtrTo(kt).Implements(textUnmarshalerType):
+			if err := kv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(k)); err != nil {
+				this.path = append(this.path, k)
+				this.fail(err)
+				this.path = this.path[:len(this.path)-1]
+				continue
+			}
+		default:
+			this.decodeAt(k, json.Number(k), kv)
+		}
+		ev := reflect.New(t.Elem()).Elem()
+		this.decodeAt(k, x, ev)
+		dst.SetMapIndex(kv, ev)
+	}
+}
+
+func (this *decoder) decodeStruct(o IReadonlyObject, dst reflect.Value) {
+	plan := planFor(dst.Type())
+	checkRequired := this.opts != nil && len(plan.required) > 0
+	var seen map[*structField]bool
+	if checkRequired {
+		seen = make(map[*structField]bool, len(plan.required))
+	}
+	for k, x := range o.ToMap() {
+		f := plan.lookup(k)
+		if f == nil {
+			if this.opts != nil && this.opts.DisallowUnknownKeys {
+				this.path = append(this.path, k)
+				this.fail(UnknownKeyError{})
+				this.path = this.path[:len(this.path)-1]
+			}
+			continue
+		}
+		if checkRequired && !isNil(&x) {
+			seen[f] = true
+		}
+		this.path = append(this.path, k)
+		if fv, ok := fieldForWrite(dst, f.index); !ok {
+			this.fail(errors.New("cannot set embedded pointer to unexported struct"))
+		} else if f.quoted {
+			this.decodeQuoted(x, fv)
+		} else {
+			this.decode(x, fv)
+		}
+		this.path = this.path[:len(this.path)-1]
+	}
+	if checkRequired {
+		for _, f := range plan.required {
+			if !seen[f] {
+				this.path = append(this.path, f.name)
+				this.fail(RequiredError{})
+				this.path = this.path[:len(this.path)-1]
+			}
+		}
+	}
+}
+
+// turns strings and numbers into values expected by decode for type t
+func coerceValue(v interface{}, t reflect.Type) interface{} {
+	s, isString := v.(string)
+	if t == durationType {
+		if isString {
+			if d, err := time.ParseDuration(strings.TrimSpace(s)); err == nil {
+				return int64(d)
+			}
+		}
+		return v
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		if isString {
+			if b, err := strconv.ParseBool(strings.TrimSpace(s)); err == nil {
+				return b
+			}
+		} else if i, ok := exactInt(v); ok && (i == 0 || i == 1) {
+			return i == 1
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		if isString {
+			s = strings.TrimSpace(s)
+			if s != "" && (s[0] == '-' || (s[0] >= '0' && s[0] <= '9')) && json.Valid([]byte(s)) {
+				return json.Number(s)
+			}
+		}
+	}
+	return v
+}
+
+// ",string" fields keep their json value inside a string
+func (this *decoder) decodeQuoted(v interface{}, dst reflect.Value) {
+	s, ok := v.(string)
+	if !ok {
+		if !isNil(&v) {
+			this.mismatch(v, dst.Type())
+		}
+		return
+	}
+	var x interface{} = json.Number(s)
+	switch {
+	case s == "null":
+		x = nil
+	case s == "true" || s == "false":
+		x = s == "true"
+	case dst.Kind() == reflect.String:
+		if err := json.Unmarshal([]byte(s), &x); err != nil {
+			this.fail(err)
+			return
+		}
+	}
+	this.decode(x, dst)
+}
+
+// allocates nil embedded pointers on the way
+func fieldForWrite(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// like IntValue, but fractions are not truncated
+func exactInt(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case float64:
+		if n != math.Trunc(n) || n < math.MinInt64 || n >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(n), true
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+	b, ok := BigIntValue(v)
+	if !ok || !b.IsInt64() {
+		return 0, false
+	}
+	return b.Int64(), true
+}
+
+//-------------------------------------
+// encoding
+
+func encodeValue(rv reflect.Value) (interface{}, error) {
+	return (&encoder{}).value(rv)
+}
+
+// keeps pointers, maps and slices being encoded right now, so cycles fail instead of
+// going forever, same as in encoding/json
+type encoder struct {
+	visiting map[visit]bool
+}
+
+type visit struct {
+	ptr uintptr
+	t   reflect.Type
+	len int
+}
+
+func (this *encoder) value(rv reflect.Value) (interface{}, error) {
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	t := rv.Type()
+	switch t {
+	case timeType:
+		return rv.Interface().(time.Time).Format(time.RFC3339Nano), nil
+	case numberType:
+		if rv.String() == "" {
+			return json.Number("0"), nil
+		}
+		return json.Number(rv.String()), nil
+	}
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return nil, nil
+		}
+	}
+	flags := typeFlagsOf(t)
+	if m, ok := implementer(rv, flags, hasMarshaler, hasPtrMarshaler); ok {
+		b, err := m.Interface().(json.Marshaler).MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		var res interface{}
+		err = json.Unmarshal(b, &res)
+		return res, err
+	}
+	if m, ok := implementer(rv, flags, hasTextMarshaler, hasPtrTextMarshaler); ok {
+		b, err := m.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		key := visit{ptr: rv.Pointer(), t: t}
+		if t.Kind() == reflect.Slice {
+			key.len = rv.Len()
+		}
+		if this.visiting[key] {
+			return nil, &json.UnsupportedValueError{Value: rv, Str: fmt.Sprintf("encountered a cycle via %s", t)}
+		}
+		if this.visiting == nil {
+			this.visiting = map[visit]bool{}
+		}
+		this.visiting[key] = true
+		defer delete(this.visiting, key)
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return this.value(rv.Elem())
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, &json.UnsupportedValueError{Value: rv, Str: strconv.FormatFloat(f, 'g', -1, 64)}
+		}
+		if t.Kind() == reflect.Float32 {
+			// shortest float32 representation, 0.1 instead of 0.10000000149011612
+			f, _ = strconv.ParseFloat(strconv.FormatFloat(f, 'g', -1, 32), 64)
+		}
+		return f, nil
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(rv.Bytes()), nil
+		}
+		res := make([]interface{}, rv.Len())
+		for i := range res {
+			x, err := this.value(rv.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			res[i] = x
+		}
+		return res, nil
+	case reflect.Map:
+		return this.mapValue(rv)
+	case reflect.Struct:
+		return this.structValue(rv)
+	}
+	return nil, &json.UnsupportedTypeError{Type: t}
+}
+
+func (this *encoder) mapValue(rv reflect.Value) (interface{}, error) {
+	res := make(map[string]interface{}, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		var key string
+		k := iter.Key()
+		switch {
+		case k.Kind() == reflect.String:
+			key = k.String()
+		case k.Type().Implements(textMarshalerType):
+			b, err := k.Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return nil, err
+			}
+			key = string(b)
+		case k.CanInt():
+			key = strconv.FormatInt(k.Int(), 10)
+		case k.CanUint():
+			key = strconv.FormatUint(k.Uint(), 10)
+		default:
+			return nil, &json.UnsupportedTypeError{Type: rv.Type()}
+		}
+		x, err := this.value(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		res[key] = x
+	}
+	return res, nil
+}
+
+func (this *encoder) structValue(rv reflect.Value) (interface{}, error) {
+	plan := planFor(rv.Type())
+	res := make(map[string]interface{}, len(plan.fields))
+	for i := range plan.fields {
+		f := &plan.fields[i]
+		fv, ok := fieldForRead(rv, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+		x, err := this.value(fv)
+		if err != nil {
+			return nil, err
+		}
+		if f.quoted && x != nil {
+			if s, ok := x.(string); ok {
+				b, _ := json.Marshal(s)
+				x = string(b)
+			} else {
+				x = fmt.Sprint(x)
+			}
+		}
+		res[f.name] = x
+	}
+	return res, nil
+}
+
+// fails if some embedded pointer is nil
+func fieldForRead(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// value itself or its address if it implements interface
+func implementer(rv reflect.Value, flags, valueFlag, ptrFlag int) (reflect.Value, bool) {
+	if flags&valueFlag != 0 {
+		return rv, true
+	}
+	if flags&ptrFlag != 0 && rv.Kind() != reflect.Ptr && rv.CanAddr() {
+		return rv.Addr(), true
+	}
+	return reflect.Value{}, false
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.Interface, reflect.Ptr:
+		return v.IsZero()
+	}
+	return false
+}
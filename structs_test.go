@@ -0,0 +1,183 @@
+package jsonlight
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type structsBase struct {
+	ID      int64 `json:"id"`
+	Created time.Time
+}
+
+type structsSample struct {
+	structsBase
+	*StructsExtra
+	Name    string         `json:"name"`
+	Count   uint8          `json:"count,omitempty"`
+	Ratio   float32        `json:"ratio"`
+	Quoted  int            `json:"quoted,string"`
+	Tags    []string       `json:"tags"`
+	Limits  map[string]int `json:"limits"`
+	Ptr     *bool          `json:"ptr"`
+	Addr    net.IP         `json:"addr"`
+	Raw     []byte         `json:"raw"`
+	Any     interface{}    `json:"any"`
+	Nested  []structsBase  `json:"nested"`
+	ByID    map[int]string `json:"by_id"`
+	Skipped string         `json:"-"`
+	private int
+}
+
+type StructsExtra struct {
+	Note string `json:"note"`
+}
+
+const structsJSON = `{"id":7,"Created":"2024-01-02T03:04:05Z","note":"n","name":"x","ratio":0.5,"quoted":"42",
+	"tags":["a","b"],"limits":{"a":1},"ptr":true,"addr":"10.0.0.1","raw":"aGk=","any":{"k":[1]},
+	"nested":[{"id":1}],"by_id":{"3":"three"},"Skipped":"no"}`
+
+func TestFillStruct(t *testing.T) {
+	var s structsSample
+	if err := NewObjectOrDie(structsJSON).FillStruct(&s); err != nil {
+		t.Fatal(err)
+	}
+	var expected structsSample
+	if err := json.Unmarshal([]byte(structsJSON), &expected); err != nil {
+		t.Fatal(err)
+	}
+	expected.Any = s.Any // numbers differ by type only
+	if !reflect.DeepEqual(s, expected) {
+		t.Errorf("expected %+v, got %+v", expected, s)
+	}
+	if !ValuesEqual(s.Any, map[string]interface{}{"k": []interface{}{1}}) {
+		t.Errorf("unexpected any %v", s.Any)
+	}
+
+	err := NewObjectOrDie(`{"name":1,"nested":[{"id":1.5}]}`).FillStruct(&s)
+	var fe FieldError
+	if !errors.As(err, &fe) || fe.Path != "/name" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestStructToMap(t *testing.T) {
+	var s structsSample
+	json.Unmarshal([]byte(structsJSON), &s)
+	o, err := StructToMap(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := json.Marshal(&s)
+	expected := NewObjectOrDie(string(b))
+	if d := Diff(expected, o); len(d) > 0 {
+		t.Errorf("unexpected differences %v", d)
+	}
+	if o.Has("count") || o.Has("Skipped") {
+		t.Error("omitempty and ignored fields should be skipped")
+	}
+}
+
+type structsNode struct {
+	Next *structsNode `json:"next"`
+	Tags map[string]interface{}
+}
+
+func TestStructToMapCycle(t *testing.T) {
+	n := &structsNode{}
+	n.Next = n
+	var uve *json.UnsupportedValueError
+	if _, err := StructToMap(n); !errors.As(err, &uve) {
+		t.Errorf("pointer cycle should fail, got %v", err)
+	}
+	m := map[string]interface{}{}
+	m["self"] = m
+	if _, err := StructToMap(&structsNode{Tags: m}); !errors.As(err, &uve) {
+		t.Errorf("map cycle should fail, got %v", err)
+	}
+
+	// same pointer twice is not a cycle
+	leaf := &structsNode{}
+	if _, err := StructToMap(map[string]interface{}{"a": leaf, "b": leaf}); err != nil {
+		t.Error(err)
+	}
+}
+
+func BenchmarkFillStruct(b *testing.B) {
+	o := NewObjectOrDie(structsJSON)
+	for i := 0; i < b.N; i++ {
+		var s structsSample
+		o.FillStruct(&s)
+	}
+}
+
+// the way FillStruct worked before
+func BenchmarkFillStructViaJSON(b *testing.B) {
+	o := NewObjectOrDie(structsJSON)
+	for i := 0; i < b.N; i++ {
+		var s structsSample
+		json.Unmarshal(o.ToByteArray(), &s)
+	}
+}
+
+func BenchmarkStructToMap(b *testing.B) {
+	var s structsSample
+	json.Unmarshal([]byte(structsJSON), &s)
+	for i := 0; i < b.N; i++ {
+		StructToMap(&s)
+	}
+}
+
+func BenchmarkStructToMapViaJSON(b *testing.B) {
+	var s structsSample
+	json.Unmarshal([]byte(structsJSON), &s)
+	for i := 0; i < b.N; i++ {
+		bytes, _ := json.Marshal(&s)
+		var m map[string]interface{}
+		json.Unmarshal(bytes, &m)
+		NewObject(m)
+	}
+}
+
+type structsLenient struct {
+	Port    int           `json:"port,required"`
+	Debug   bool          `json:"debug"`
+	Timeout time.Duration `json:"timeout"`
+	Since   time.Time     `json:"since"`
+	Ratio   *float64      `json:"ratio"`
+	Name    string        `json:"name,required"`
+}
+
+func TestFillStructWithOptions(t *testing.T) {
+	o := NewObjectOrDie(`{"port":" 8080","debug":1,"timeout":"1m30s","since":"2024-01-02","ratio":"0.25","name":"x"}`)
+	var s structsLenient
+	if err := o.FillStructWithOptions(&s, FillOptions{Coerce: true}); err != nil {
+		t.Fatal(err)
+	}
+	if s.Port != 8080 || !s.Debug || s.Timeout != 90*time.Second || s.Since.Day() != 2 || *s.Ratio != 0.25 {
+		t.Errorf("unexpected result %+v", s)
+	}
+
+	o = NewObjectOrDie(`{"port":"x","debug":"maybe","timeout":"1m30s","extra":1}`)
+	err := o.FillStructWithOptions(&s, FillOptions{DisallowUnknownKeys: true})
+	errs, ok := err.(FillErrors)
+	if !ok {
+		t.Fatalf("expected FillErrors, got %v", err)
+	}
+	expected := []string{"/debug", "/extra", "/name", "/port", "/timeout"}
+	if len(errs) != len(expected) {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	for i, e := range errs {
+		if e.Path != expected[i] {
+			t.Errorf("expected error at %s, got %v", expected[i], e)
+		}
+	}
+	if !errors.Is(err, UnknownKeyError{}) || !errors.Is(err, RequiredError{}) {
+		t.Error("errors.Is should see field errors")
+	}
+}
@@ -2,7 +2,9 @@ package jsonlight
 
 import (
 	"io"
+	"math/big"
 	"sync"
+	"time"
 )
 
 // it should be easy to add super functionality to existing map using simple type casting
@@ -113,6 +115,12 @@ func (this *SynchronizedObjectWrapper) FillStruct(s interface{}) error {
 	return this.O.FillStruct(s)
 }
 
+func (this *SynchronizedObjectWrapper) FillStructWithOptions(s interface{}, opts FillOptions) error {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.FillStructWithOptions(s, opts)
+}
+
 //-------------------------------------------------------
 
 func (this *SynchronizedObjectWrapper) Get(key string) (interface{}, bool) {
@@ -161,6 +169,61 @@ func (this *SynchronizedObjectWrapper) GetLong(key string) (int64, error) {
 	defer this.Mutex.Unlock()
 	return this.O.GetLong(key)
 }
+func (this *SynchronizedObjectWrapper) GetBigInt(key string) (*big.Int, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.GetBigInt(key)
+}
+func (this *SynchronizedObjectWrapper) GetUint64(key string) (uint64, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.GetUint64(key)
+}
+func (this *SynchronizedObjectWrapper) GetTime(key string, layouts ...string) (time.Time, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.GetTime(key, layouts...)
+}
+func (this *SynchronizedObjectWrapper) GetDuration(key string) (time.Duration, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.GetDuration(key)
+}
+func (this *SynchronizedObjectWrapper) GetBytes(key string) ([]byte, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.GetBytes(key)
+}
+func (this *SynchronizedObjectWrapper) OptTime(key string, defaultvalue ...time.Time) time.Time {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.OptTime(key, defaultvalue...)
+}
+func (this *SynchronizedObjectWrapper) OptDuration(key string, defaultvalue ...time.Duration) time.Duration {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.OptDuration(key, defaultvalue...)
+}
+func (this *SynchronizedObjectWrapper) OptBytes(key string, defaultvalue ...[]byte) []byte {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.OptBytes(key, defaultvalue...)
+}
+func (this *SynchronizedObjectWrapper) PutTime(key string, t time.Time, layout ...string) (interface{}, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.PutTime(key, t, layout...)
+}
+func (this *SynchronizedObjectWrapper) PutDuration(key string, d time.Duration) (interface{}, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.PutDuration(key, d)
+}
+func (this *SynchronizedObjectWrapper) PutBytes(key string, b []byte) (interface{}, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.PutBytes(key, b)
+}
 
 //---------------------
 
@@ -204,3 +267,109 @@ func (this *SynchronizedObjectWrapper) OptLong(key string, defaultvalue ...int64
 	defer this.Mutex.Unlock()
 	return this.O.OptLong(key, defaultvalue...)
 }
+
+//-------------------------------------
+
+func (this *SynchronizedObjectWrapper) GetPointer(pointer string) (interface{}, bool) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.GetPointer(pointer)
+}
+func (this *SynchronizedObjectWrapper) HasPointer(pointer string) bool {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.HasPointer(pointer)
+}
+func (this *SynchronizedObjectWrapper) PutPointer(pointer string, value interface{}, createMissing ...bool) (interface{}, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.PutPointer(pointer, value, createMissing...)
+}
+func (this *SynchronizedObjectWrapper) RemovePointer(pointer string) interface{} {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.RemovePointer(pointer)
+}
+func (this *SynchronizedObjectWrapper) GetBooleanPointer(pointer string) (bool, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.GetBooleanPointer(pointer)
+}
+func (this *SynchronizedObjectWrapper) GetDoublePointer(pointer string) (float64, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.GetDoublePointer(pointer)
+}
+func (this *SynchronizedObjectWrapper) GetIntPointer(pointer string) (int, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.GetIntPointer(pointer)
+}
+func (this *SynchronizedObjectWrapper) GetArrayPointer(pointer string) (IArray, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.GetArrayPointer(pointer)
+}
+func (this *SynchronizedObjectWrapper) GetObjectPointer(pointer string) (IObject, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.GetObjectPointer(pointer)
+}
+func (this *SynchronizedObjectWrapper) GetLongPointer(pointer string) (int64, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.GetLongPointer(pointer)
+}
+func (this *SynchronizedObjectWrapper) GetStringPointer(pointer string) (string, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.GetStringPointer(pointer)
+}
+func (this *SynchronizedObjectWrapper) OptBooleanPointer(pointer string, defaultvalue ...bool) bool {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.OptBooleanPointer(pointer, defaultvalue...)
+}
+func (this *SynchronizedObjectWrapper) OptDoublePointer(pointer string, defaultvalue ...float64) float64 {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.OptDoublePointer(pointer, defaultvalue...)
+}
+func (this *SynchronizedObjectWrapper) OptIntPointer(pointer string, defaultvalue ...int) int {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.OptIntPointer(pointer, defaultvalue...)
+}
+func (this *SynchronizedObjectWrapper) OptArrayPointer(pointer string, defaultvalue ...IArray) IArray {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.OptArrayPointer(pointer, defaultvalue...)
+}
+func (this *SynchronizedObjectWrapper) OptObjectPointer(pointer string, defaultvalue ...IObject) IObject {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.OptObjectPointer(pointer, defaultvalue...)
+}
+func (this *SynchronizedObjectWrapper) OptLongPointer(pointer string, defaultvalue ...int64) int64 {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.OptLongPointer(pointer, defaultvalue...)
+}
+func (this *SynchronizedObjectWrapper) OptStringPointer(pointer string, defaultvalue ...string) string {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.OptStringPointer(pointer, defaultvalue...)
+}
+
+//-------------------------------------
+
+func (this *SynchronizedObjectWrapper) Query(expr string) (IArray, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.Query(expr)
+}
+func (this *SynchronizedObjectWrapper) QueryPaths(expr string) ([]string, error) {
+	this.Mutex.Lock()
+	defer this.Mutex.Unlock()
+	return this.O.QueryPaths(expr)
+}
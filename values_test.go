@@ -0,0 +1,48 @@
+package jsonlight
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTimeDurationBytes(t *testing.T) {
+	o := NewObjectOrDie(`{"rfc":"2024-01-02T03:04:05Z","unix":1704164645,"millis":1704164645000,
+		"custom":"02.01.2024","timeout":"1m30s","secs":1.5,"blob":"aGVsbG8=","raw":"aGVsbG8"}`)
+	expected := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, k := range []string{"rfc", "unix", "millis"} {
+		if v, err := o.GetTime(k); err != nil || !v.Equal(expected) {
+			t.Errorf("%s: unexpected %v %v", k, v, err)
+		}
+	}
+	if v, err := o.GetTime("custom", "02.01.2006"); err != nil || v.Day() != 2 {
+		t.Errorf("unexpected %v %v", v, err)
+	}
+	if _, err := o.GetTime("custom"); err == nil {
+		t.Error("custom layout should be required")
+	}
+	if !o.OptTime("custom", expected).Equal(expected) || !o.OptTime("missing").IsZero() {
+		t.Error("OptTime default expected")
+	}
+	if o.OptDuration("timeout") != 90*time.Second || o.OptDuration("secs") != 1500*time.Millisecond {
+		t.Error("unexpected durations")
+	}
+	if o.OptDuration("blob", time.Hour) != time.Hour {
+		t.Error("default expected")
+	}
+	if !bytes.Equal(o.OptBytes("blob"), []byte("hello")) || !bytes.Equal(o.OptBytes("raw"), []byte("hello")) {
+		t.Error("unexpected bytes")
+	}
+
+	a := NewEmptyArray()
+	a.Append(nil, nil, nil)
+	a.PutTime(0, expected)
+	a.PutDuration(1, time.Minute)
+	a.PutBytes(2, []byte("hi"))
+	if a.ToString() != `["2024-01-02T03:04:05Z","1m0s","aGk="]` {
+		t.Errorf("unexpected %s", a.ToString())
+	}
+	if v, err := a.GetDuration(1); err != nil || v != time.Minute {
+		t.Errorf("unexpected %v %v", v, err)
+	}
+}
@@ -0,0 +1,494 @@
+package jsonlight
+
+import (
+	"errors"
+	"io"
+	"iter"
+	"math/big"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// read-only object backed by file which is reloaded when the file changes.
+// file is checked every Interval and, on linux, right after inotify reports a change.
+// new content replaces old one atomically: every call works with a complete version,
+// use Current() to get a snapshot for several calls. if new content cannot be loaded,
+// the last good version stays and error callbacks are called.
+// snapshots are shared, so they should not be modified
+type WatchedObject struct {
+	path string
+	opts WatchOptions
+
+	current atomic.Pointer[watchedSnapshot]
+
+	mu        sync.Mutex
+	onChange  []func(changes []Change, current IReadonlyObject)
+	onError   []func(err error)
+	lastError error
+	// results of loads waiting for callbacks, in order of loads
+	pending    []watchEvent
+	delivering bool
+
+	// serializes reloads
+	reloadMu sync.Mutex
+	stamp    fileStamp
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type WatchOptions struct {
+	// how often file is checked, 1s by default
+	Interval time.Duration
+	// do not use inotify even where it is available
+	PollOnly bool
+	// parses the file, NewObjectFromFile by default. only this file is watched
+	Load func(path string) (IObject, error)
+	// passed to Diff for change callbacks
+	DiffOptions DiffOptions
+}
+
+type watchedSnapshot struct {
+	o IObject
+}
+
+// what callbacks are called with: changes or error
+type watchEvent struct {
+	changes []Change
+	current IReadonlyObject
+	err     error
+}
+
+// modification time and size, file is reloaded when they change
+type fileStamp struct {
+	mtime time.Time
+	size  int64
+}
+
+// loads file and starts watching it, error is returned if the first load fails
+func WatchFile(path string, opts ...WatchOptions) (*WatchedObject, error) {
+	this := &WatchedObject{path: path, stop: make(chan struct{}), done: make(chan struct{})}
+	if len(opts) > 0 {
+		this.opts = opts[0]
+	}
+	if this.opts.Interval <= 0 {
+		this.opts.Interval = time.Second
+	}
+	if this.opts.Load == nil {
+		this.opts.Load = func(path string) (IObject, error) {
+			o, err, _ := NewObjectFromFile(path, 0)
+			return o, err
+		}
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		this.stamp = fileStamp{info.ModTime(), info.Size()}
+	}
+	o, err := this.opts.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	this.current.Store(&watchedSnapshot{o})
+
+	var events <-chan struct{}
+	var stopEvents func()
+	if !this.opts.PollOnly {
+		events, stopEvents, _ = notifyFileChanges(path)
+	}
+	go this.loop(events, stopEvents)
+	return this, nil
+}
+
+func (this *WatchedObject) loop(events <-chan struct{}, stopEvents func()) {
+	defer close(this.done)
+	if stopEvents != nil {
+		defer stopEvents()
+	}
+	ticker := time.NewTicker(this.opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-this.stop:
+			return
+		case <-ticker.C:
+		case _, ok := <-events:
+			if !ok {
+				// notifications stopped, polling goes on
+				events = nil
+			}
+		}
+		this.reload(false)
+	}
+}
+
+// called with changes after every successful reload which changed something
+func (this *WatchedObject) OnChange(fn func(changes []Change, current IReadonlyObject)) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.onChange = append(this.onChange, fn)
+}
+
+// called when changed file cannot be read or parsed
+func (this *WatchedObject) OnError(fn func(err error)) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.onError = append(this.onError, fn)
+}
+
+// error of the last reload, nil if it succeeded
+func (this *WatchedObject) LastError() error {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	return this.lastError
+}
+
+// snapshot of current version, it stays the same after reloads
+func (this *WatchedObject) Current() IReadonlyObject {
+	return this.current.Load().o
+}
+
+// reloads file right now, even if it looks unchanged.
+// if callbacks of earlier reload are running, callbacks of this one are called after them
+// by the same goroutine, so Reload may return before they are called
+func (this *WatchedObject) Reload() error {
+	return this.reload(true)
+}
+
+// stops watching, last version stays available
+func (this *WatchedObject) Close() {
+	this.closeOnce.Do(func() { close(this.stop) })
+	<-this.done
+}
+
+func (this *WatchedObject) reload(force bool) error {
+	err := this.load(force)
+	this.deliver()
+	return err
+}
+
+// reads the file and makes it current version, no changes if file looks the same.
+// result is queued for callbacks while reloadMu is held, so queue keeps order of versions
+func (this *WatchedObject) load(force bool) error {
+	this.reloadMu.Lock()
+	defer this.reloadMu.Unlock()
+
+	var stamp fileStamp
+	info, err := os.Stat(this.path)
+	if err == nil {
+		stamp = fileStamp{info.ModTime(), info.Size()}
+	}
+	if !force && stamp == this.stamp {
+		return nil
+	}
+
+	var o IObject
+	if err == nil {
+		o, err = this.opts.Load(this.path)
+	}
+	if err == nil && o == nil {
+		err = errors.New("nothing loaded")
+	}
+	if err != nil {
+		// stamp stays old, so the file is tried again on next check
+		this.queue(watchEvent{err: err})
+		return err
+	}
+
+	this.stamp = stamp
+	changes := Diff(this.Current(), o, this.opts.DiffOptions)
+	this.current.Store(&watchedSnapshot{o})
+	this.queue(watchEvent{changes: changes, current: o})
+	return nil
+}
+
+// remembers result of load, events without changes are not queued
+func (this *WatchedObject) queue(e watchEvent) {
+	this.mu.Lock()
+	defer this.mu.Unlock()
+	this.lastError = e.err
+	if e.err != nil || len(e.changes) > 0 {
+		this.pending = append(this.pending, e)
+	}
+}
+
+// calls callbacks for queued events one by one, without holding any lock.
+// only one goroutine delivers at a time, so callbacks see versions in order,
+// and a callback may call Reload: its events are delivered when the callback returns
+func (this *WatchedObject) deliver() {
+	this.mu.Lock()
+	if this.delivering {
+		this.mu.Unlock()
+		return
+	}
+	this.delivering = true
+	this.mu.Unlock()
+
+	finished := false
+	defer func() {
+		if !finished {
+			// callback panicked, the next reload goes on with the queue
+			this.mu.Lock()
+			this.delivering = false
+			this.mu.Unlock()
+		}
+	}()
+	for {
+		this.mu.Lock()
+		if len(this.pending) == 0 {
+			this.delivering = false
+			this.mu.Unlock()
+			finished = true
+			return
+		}
+		e := this.pending[0]
+		this.pending = this.pending[1:]
+		onChange := append([]func([]Change, IReadonlyObject){}, this.onChange...)
+		onError := append([]func(error){}, this.onError...)
+		this.mu.Unlock()
+
+		if e.err != nil {
+			for _, fn := range onError {
+				fn(e.err)
+			}
+			continue
+		}
+		for _, fn := range onChange {
+			fn(e.changes, e.current)
+		}
+	}
+}
+
+func (this *WatchedObject) ToReadonlyObject() IReadonlyObject {
+	return this
+}
+
+func (this *WatchedObject) Length() int {
+	return this.Current().Length()
+}
+
+func (this *WatchedObject) Get(key string) (interface{}, bool) {
+	return this.Current().Get(key)
+}
+
+func (this *WatchedObject) GetBoolean(key string) (bool, error) {
+	return this.Current().GetBoolean(key)
+}
+
+func (this *WatchedObject) GetDouble(key string) (float64, error) {
+	return this.Current().GetDouble(key)
+}
+
+func (this *WatchedObject) GetInt(key string) (int, error) {
+	return this.Current().GetInt(key)
+}
+
+func (this *WatchedObject) GetArray(key string) (IArray, error) {
+	return this.Current().GetArray(key)
+}
+
+func (this *WatchedObject) GetObject(key string) (IObject, error) {
+	return this.Current().GetObject(key)
+}
+
+func (this *WatchedObject) GetLong(key string) (int64, error) {
+	return this.Current().GetLong(key)
+}
+
+func (this *WatchedObject) GetString(key string) (string, error) {
+	return this.Current().GetString(key)
+}
+
+func (this *WatchedObject) GetBigInt(key string) (*big.Int, error) {
+	return this.Current().GetBigInt(key)
+}
+
+func (this *WatchedObject) GetUint64(key string) (uint64, error) {
+	return this.Current().GetUint64(key)
+}
+
+func (this *WatchedObject) GetTime(key string, layouts ...string) (time.Time, error) {
+	return this.Current().GetTime(key, layouts...)
+}
+
+func (this *WatchedObject) GetDuration(key string) (time.Duration, error) {
+	return this.Current().GetDuration(key)
+}
+
+func (this *WatchedObject) GetBytes(key string) ([]byte, error) {
+	return this.Current().GetBytes(key)
+}
+
+func (this *WatchedObject) Has(key string) bool {
+	return this.Current().Has(key)
+}
+
+func (this *WatchedObject) IsNull(key string) bool {
+	return this.Current().IsNull(key)
+}
+
+func (this *WatchedObject) Opt(key string, defaultvalue ...interface{}) interface{} {
+	return this.Current().Opt(key, defaultvalue...)
+}
+
+func (this *WatchedObject) OptBoolean(key string, defaultvalue ...bool) bool {
+	return this.Current().OptBoolean(key, defaultvalue...)
+}
+
+func (this *WatchedObject) OptDouble(key string, defaultvalue ...float64) float64 {
+	return this.Current().OptDouble(key, defaultvalue...)
+}
+
+func (this *WatchedObject) OptInt(key string, defaultvalue ...int) int {
+	return this.Current().OptInt(key, defaultvalue...)
+}
+
+func (this *WatchedObject) OptArray(key string, defaultvalue ...IArray) IArray {
+	return this.Current().OptArray(key, defaultvalue...)
+}
+
+func (this *WatchedObject) OptObject(key string, defaultvalue ...IObject) IObject {
+	return this.Current().OptObject(key, defaultvalue...)
+}
+
+func (this *WatchedObject) OptLong(key string, defaultvalue ...int64) int64 {
+	return this.Current().OptLong(key, defaultvalue...)
+}
+
+func (this *WatchedObject) OptString(key string, defaultvalue ...string) string {
+	return this.Current().OptString(key, defaultvalue...)
+}
+
+func (this *WatchedObject) OptTime(key string, defaultvalue ...time.Time) time.Time {
+	return this.Current().OptTime(key, defaultvalue...)
+}
+
+func (this *WatchedObject) OptDuration(key string, defaultvalue ...time.Duration) time.Duration {
+	return this.Current().OptDuration(key, defaultvalue...)
+}
+
+func (this *WatchedObject) OptBytes(key string, defaultvalue ...[]byte) []byte {
+	return this.Current().OptBytes(key, defaultvalue...)
+}
+
+func (this *WatchedObject) ToString(indentFactor ...int) string {
+	return this.Current().ToString(indentFactor...)
+}
+
+func (this *WatchedObject) ToByteArray(indentFactor ...int) []byte {
+	return this.Current().ToByteArray(indentFactor...)
+}
+
+func (this *WatchedObject) Write(writer *io.Writer) {
+	this.Current().Write(writer)
+}
+
+func (this *WatchedObject) ToArray(names ...string) IArray {
+	return this.Current().ToArray(names...)
+}
+
+func (this *WatchedObject) ToMap() map[string]interface{} {
+	return this.Current().ToMap()
+}
+
+func (this *WatchedObject) Keys() []string {
+	return this.Current().Keys()
+}
+
+func (this *WatchedObject) All() iter.Seq2[string, interface{}] {
+	return this.Current().All()
+}
+
+func (this *WatchedObject) SortedAll() iter.Seq2[string, interface{}] {
+	return this.Current().SortedAll()
+}
+
+func (this *WatchedObject) Objects() iter.Seq2[string, IObject] {
+	return this.Current().Objects()
+}
+
+func (this *WatchedObject) Arrays() iter.Seq2[string, IArray] {
+	return this.Current().Arrays()
+}
+
+func (this *WatchedObject) Strings() iter.Seq2[string, string] {
+	return this.Current().Strings()
+}
+
+func (this *WatchedObject) Walk() iter.Seq2[string, interface{}] {
+	return this.Current().Walk()
+}
+
+func (this *WatchedObject) GetPointer(pointer string) (interface{}, bool) {
+	return this.Current().GetPointer(pointer)
+}
+
+func (this *WatchedObject) HasPointer(pointer string) bool {
+	return this.Current().HasPointer(pointer)
+}
+
+func (this *WatchedObject) GetBooleanPointer(pointer string) (bool, error) {
+	return this.Current().GetBooleanPointer(pointer)
+}
+
+func (this *WatchedObject) GetDoublePointer(pointer string) (float64, error) {
+	return this.Current().GetDoublePointer(pointer)
+}
+
+func (this *WatchedObject) GetIntPointer(pointer string) (int, error) {
+	return this.Current().GetIntPointer(pointer)
+}
+
+func (this *WatchedObject) GetArrayPointer(pointer string) (IArray, error) {
+	return this.Current().GetArrayPointer(pointer)
+}
+
+func (this *WatchedObject) GetObjectPointer(pointer string) (IObject, error) {
+	return this.Current().GetObjectPointer(pointer)
+}
+
+func (this *WatchedObject) GetLongPointer(pointer string) (int64, error) {
+	return this.Current().GetLongPointer(pointer)
+}
+
+func (this *WatchedObject) GetStringPointer(pointer string) (string, error) {
+	return this.Current().GetStringPointer(pointer)
+}
+
+func (this *WatchedObject) OptBooleanPointer(pointer string, defaultvalue ...bool) bool {
+	return this.Current().OptBooleanPointer(pointer, defaultvalue...)
+}
+
+func (this *WatchedObject) OptDoublePointer(pointer string, defaultvalue ...float64) float64 {
+	return this.Current().OptDoublePointer(pointer, defaultvalue...)
+}
+
+func (this *WatchedObject) OptIntPointer(pointer string, defaultvalue ...int) int {
+	return this.Current().OptIntPointer(pointer, defaultvalue...)
+}
+
+func (this *WatchedObject) OptArrayPointer(pointer string, defaultvalue ...IArray) IArray {
+	return this.Current().OptArrayPointer(pointer, defaultvalue...)
+}
+
+func (this *WatchedObject) OptObjectPointer(pointer string, defaultvalue ...IObject) IObject {
+	return this.Current().OptObjectPointer(pointer, defaultvalue...)
+}
+
+func (this *WatchedObject) OptLongPointer(pointer string, defaultvalue ...int64) int64 {
+	return this.Current().OptLongPointer(pointer, defaultvalue...)
+}
+
+func (this *WatchedObject) OptStringPointer(pointer string, defaultvalue ...string) string {
+	return this.Current().OptStringPointer(pointer, defaultvalue...)
+}
+
+func (this *WatchedObject) Query(expr string) (IArray, error) {
+	return this.Current().Query(expr)
+}
+
+func (this *WatchedObject) QueryPaths(expr string) ([]string, error) {
+	return this.Current().QueryPaths(expr)
+}
@@ -0,0 +1,55 @@
+//go:build linux
+
+package jsonlight
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// signals when file is written, replaced or removed, using inotify on its directory,
+// so editors which save via rename are handled too. channel is closed when watching stops
+func notifyFileChanges(path string) (<-chan struct{}, func(), error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_NONBLOCK | syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, nil, err
+	}
+	mask := uint32(syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_TO | syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MOVED_FROM)
+	if _, err := syscall.InotifyAddWatch(fd, filepath.Dir(path), mask); err != nil {
+		syscall.Close(fd)
+		return nil, nil, err
+	}
+	// non-blocking fd goes to runtime poller, so Close interrupts Read
+	f := os.NewFile(uintptr(fd), "inotify")
+	name := []byte(filepath.Base(path))
+	ch := make(chan struct{}, 1)
+
+	go func() {
+		defer close(ch)
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := f.Read(buf)
+			if err != nil {
+				return
+			}
+			for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+				l := int(binary.NativeEndian.Uint32(buf[off+12:]))
+				start := off + syscall.SizeofInotifyEvent
+				if start+l > n {
+					break
+				}
+				if bytes.Equal(bytes.TrimRight(buf[start:start+l], "\x00"), name) {
+					select {
+					case ch <- struct{}{}:
+					default:
+					}
+				}
+				off = start + l
+			}
+		}
+	}()
+	return ch, func() { f.Close() }, nil
+}
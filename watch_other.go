@@ -0,0 +1,12 @@
+//go:build !linux
+
+package jsonlight
+
+import (
+	"errors"
+)
+
+// only polling is used on other systems
+func notifyFileChanges(path string) (<-chan struct{}, func(), error) {
+	return nil, nil, errors.New("file notifications are not supported")
+}
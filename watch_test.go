@@ -0,0 +1,199 @@
+package jsonlight
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchFile(t *testing.T) {
+	for _, pollOnly := range []bool{true, false} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "conf.json")
+		version := 0
+		write := func(content string) {
+			// rename keeps readers from seeing half-written file, new mtime makes change visible to polling
+			version++
+			tmp := filepath.Join(dir, "tmp")
+			os.WriteFile(tmp, []byte(content), 0644)
+			stamp := time.Now().Add(time.Duration(version) * time.Second)
+			os.Chtimes(tmp, stamp, stamp)
+			if err := os.Rename(tmp, path); err != nil {
+				t.Fatal(err)
+			}
+		}
+		write(`{"a":1,"b":{"c":"x"}}`)
+
+		interval := 20 * time.Millisecond
+		if !pollOnly {
+			// inotify should win long before the first poll
+			interval = time.Hour
+		}
+		w, err := WatchFile(path, WatchOptions{Interval: interval, PollOnly: pollOnly})
+		if err != nil {
+			t.Fatal(err)
+		}
+		changes := make(chan []Change, 10)
+		errs := make(chan error, 10)
+		w.OnChange(func(c []Change, current IReadonlyObject) { changes <- c })
+		w.OnError(func(err error) { errs <- err })
+
+		if w.OptInt("a") != 1 || w.OptObject("b").OptString("c") != "x" {
+			t.Fatalf("initial: %s", w.ToString())
+		}
+
+		write(`{"a":2,"b":{"c":"x"}}`)
+		select {
+		case c := <-changes:
+			if len(c) != 1 || c[0].Path != "/a" || c[0].Kind != ChangeModified {
+				t.Errorf("changes: %v", c)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("pollOnly=%v: change was not noticed", pollOnly)
+		}
+		if w.OptInt("a") != 2 {
+			t.Errorf("after change: %s", w.ToString())
+		}
+
+		snapshot := w.Current()
+		write(`{"a":`)
+		select {
+		case <-errs:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("pollOnly=%v: error was not reported", pollOnly)
+		}
+		if w.LastError() == nil || w.OptInt("a") != 2 || w.Current() != snapshot {
+			t.Errorf("last good version should stay: %s, %v", w.ToString(), w.LastError())
+		}
+
+		// reload of unchanged content does not call callbacks
+		write(`{"a":2,"b":{"c":"x"}}`)
+		if err := w.Reload(); err != nil || w.LastError() != nil {
+			t.Errorf("reload: %v", err)
+		}
+		w.Close()
+		select {
+		case c := <-changes:
+			t.Errorf("unexpected changes: %v", c)
+		default:
+		}
+		if w.OptInt("a") != 2 {
+			t.Errorf("closed object should keep last version: %s", w.ToString())
+		}
+	}
+}
+
+func TestWatchReloadFromCallback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conf.json")
+	os.WriteFile(path, []byte(`{"a":1}`), 0644)
+	w, err := WatchFile(path, WatchOptions{Interval: time.Hour, PollOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded := make(chan error, 1)
+	w.OnChange(func(c []Change, current IReadonlyObject) {
+		// nothing changed since, so this does not call callbacks again
+		reloaded <- w.Reload()
+	})
+	os.WriteFile(path, []byte(`{"a":2}`), 0644)
+	done := make(chan struct{})
+	go func() {
+		w.Reload()
+		w.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Reload from callback deadlocked")
+	}
+	if err := <-reloaded; err != nil || w.OptInt("a") != 2 {
+		t.Errorf("reload: %v, %s", err, w.ToString())
+	}
+}
+
+func TestWatchRetriesFailedLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conf.json")
+	os.WriteFile(path, []byte(`{"a":1}`), 0644)
+	var calls atomic.Int32
+	load := func(path string) (IObject, error) {
+		if calls.Add(1) == 2 {
+			return nil, errors.New("temporary failure")
+		}
+		o, err, _ := NewObjectFromFile(path, 0)
+		return o, err
+	}
+	w, err := WatchFile(path, WatchOptions{Interval: 10 * time.Millisecond, PollOnly: true, Load: load})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	changed := make(chan struct{}, 1)
+	w.OnChange(func(c []Change, current IReadonlyObject) {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+
+	stamp := time.Now().Add(time.Hour)
+	os.WriteFile(path, []byte(`{"a":2}`), 0644)
+	os.Chtimes(path, stamp, stamp)
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("file was not loaded again after failure")
+	}
+	if w.OptInt("a") != 2 || w.LastError() != nil {
+		t.Errorf("unexpected %s, %v", w.ToString(), w.LastError())
+	}
+}
+
+func TestWatchCallbacksInOrder(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "conf.json")
+	os.WriteFile(path, []byte(`{"v":0}`), 0644)
+	w, err := WatchFile(path, WatchOptions{Interval: time.Hour, PollOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	var mu sync.Mutex
+	last := int64(0)
+	w.OnChange(func(c []Change, current IReadonlyObject) {
+		v := current.OptLong("v")
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		if v <= last {
+			t.Errorf("version %d delivered after %d", v, last)
+		}
+		last = v
+	})
+
+	var wmu sync.Mutex
+	version := 0
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				wmu.Lock()
+				version++
+				tmp := filepath.Join(dir, fmt.Sprint("tmp", version))
+				os.WriteFile(tmp, []byte(fmt.Sprintf(`{"v":%d}`, version)), 0644)
+				os.Rename(tmp, path)
+				wmu.Unlock()
+				w.Reload()
+			}
+		}()
+	}
+	wg.Wait()
+}